@@ -0,0 +1,316 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// The comment marking a type to generate functions for.
+const marker = "+objdiff:gen"
+
+// The name of the generated file.
+const defaultOutput = "zz_generated.objdiff.go"
+
+// The builtin types whose fields are compared and copied directly.
+var basicTypes = map[string]bool{
+	"bool": true, "string": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"byte": true, "rune": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+}
+
+// The methods used by obj_diff.CopyReflectValue in place of copying a type.
+var deepCopyMethods = map[string]bool{"DeepCopy": true, "DeepCopyInto": true, "DeepCopyObject": true}
+
+type fieldKind int
+
+const (
+	// A builtin basic type, handled statically.
+	basicField fieldKind = iota
+	// A marked struct type, handled by its generated functions.
+	structField
+	// Anything else, handled reflectively.
+	otherField
+)
+
+type fieldInfo struct {
+	Index int
+	Name  string
+	// The name of the type for basic and struct fields.
+	Type     string
+	Kind     fieldKind
+	Exported bool
+}
+
+func (f fieldInfo) IsBasic() bool {
+	return f.Kind == basicField
+}
+
+func (f fieldInfo) IsStruct() bool {
+	return f.Kind == structField
+}
+
+type typeInfo struct {
+	Name   string
+	Fields []fieldInfo
+	// True if the type has unexported fields, these can only be diffed
+	// reflectively.
+	Reflective bool
+	// True if the type has a DeepCopy method that copies must use.
+	HasDeepCopy bool
+}
+
+func (t typeInfo) CopyReflectively() bool {
+	return t.Reflective || t.HasDeepCopy
+}
+
+// Returns the exported basic fields, which ApplyX sets directly.
+func (t typeInfo) SettableFields() []fieldInfo {
+	var settable []fieldInfo
+	for _, field := range t.Fields {
+		if field.IsBasic() && field.Exported {
+			settable = append(settable, field)
+		}
+	}
+	return settable
+}
+
+// Parses the package in dir and returns the formatted source of the
+// generated file. The file named output is ignored so the previous output
+// does not affect the next.
+func Generate(dir string, output string) ([]byte, error) {
+	fset := token.NewFileSet()
+	filter := func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != output
+	}
+	pkgs, err := parser.ParseDir(fset, dir, filter, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %v, found %v", dir, len(pkgs))
+	}
+
+	for _, pkg := range pkgs {
+		types, err := markedTypes(pkg)
+		if err != nil {
+			return nil, err
+		}
+		if len(types) == 0 {
+			return nil, fmt.Errorf("no types marked with %v in %v", marker, dir)
+		}
+
+		var buf bytes.Buffer
+		if err := generatedTemplate.Execute(&buf, map[string]interface{}{"Package": pkg.Name, "Types": types}); err != nil {
+			return nil, err
+		}
+		return format.Source(buf.Bytes())
+	}
+	return nil, nil
+}
+
+// Returns the marked types in pkg sorted by name.
+func markedTypes(pkg *ast.Package) ([]typeInfo, error) {
+	specs := map[string]*ast.TypeSpec{}
+	deepCopied := map[string]bool{}
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					// The doc of an ungrouped declaration belongs to the GenDecl.
+					if hasMarker(ts.Doc) || (len(decl.Specs) == 1 && hasMarker(decl.Doc)) {
+						specs[ts.Name.Name] = ts
+					}
+				}
+			case *ast.FuncDecl:
+				if decl.Recv != nil && len(decl.Recv.List) == 1 && deepCopyMethods[decl.Name.Name] {
+					deepCopied[receiverName(decl.Recv.List[0].Type)] = true
+				}
+			}
+		}
+	}
+
+	var types []typeInfo
+	for name, ts := range specs {
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			return nil, fmt.Errorf("%v is marked with %v but is not a struct", name, marker)
+		}
+
+		info := typeInfo{Name: name, HasDeepCopy: deepCopied[name]}
+		index := 0
+		for _, field := range st.Fields.List {
+			names := []string{}
+			for _, ident := range field.Names {
+				names = append(names, ident.Name)
+			}
+			if len(names) == 0 {
+				// An embedded field is named after its type.
+				names = append(names, receiverName(field.Type))
+			}
+
+			for _, fieldName := range names {
+				fi := fieldInfo{Index: index, Name: fieldName, Kind: otherField, Exported: ast.IsExported(fieldName)}
+				if ident, ok := field.Type.(*ast.Ident); ok && len(field.Names) > 0 {
+					fi.Type = ident.Name
+					if basicTypes[ident.Name] {
+						fi.Kind = basicField
+					} else if specs[ident.Name] != nil {
+						fi.Kind = structField
+					}
+				}
+				if !fi.Exported {
+					info.Reflective = true
+				}
+				info.Fields = append(info.Fields, fi)
+				index++
+			}
+		}
+		types = append(types, info)
+	}
+
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].Name < types[j].Name
+	})
+	return types, nil
+}
+
+func hasMarker(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, comment := range doc.List {
+		if strings.TrimSpace(strings.TrimPrefix(comment.Text, "//")) == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the name of the type in a receiver or embedded field, such as Foo
+// for *Foo or pkg.Foo.
+func receiverName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(expr.X)
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
+
+var generatedTemplate = template.Must(template.New("generated").Parse(`// Code generated by objdiff-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"reflect"
+
+	"github.com/walmartlabs/object-diff/pkg/obj_diff"
+	"github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+)
+{{range .Types}}
+// Diff{{.Name}} computes the change set between a and b, the same as obj_diff.Diff.
+func Diff{{.Name}}(a {{.Name}}, b {{.Name}}) (*obj_diff.ChangeSet, error) {
+	cs := &obj_diff.ChangeSet{BaseType: reflect.TypeOf((*{{.Name}})(nil)).Elem()}
+	return cs, diff{{.Name}}(cs, []helpers.PathElement{}, &a, &b)
+}
+
+func diff{{.Name}}(cs *obj_diff.ChangeSet, path []helpers.PathElement, a *{{.Name}}, b *{{.Name}}) error {
+{{- if .Reflective}}
+	return obj_diff.DiffValuesAt(cs, path, reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem(), obj_diff.DiffOptions{})
+{{- else}}
+{{- range .Fields}}
+{{- if .IsBasic}}
+	if a.{{.Name}} != b.{{.Name}} {
+		cs.AddPathChange(append(path[:len(path):len(path)], helpers.NewFieldElem({{.Index}}, "{{.Name}}")), reflect.ValueOf(a.{{.Name}}), reflect.ValueOf(b.{{.Name}}))
+	}
+{{- else if .IsStruct}}
+	if err := diff{{.Type}}(cs, append(path[:len(path):len(path)], helpers.NewFieldElem({{.Index}}, "{{.Name}}")), &a.{{.Name}}, &b.{{.Name}}); err != nil {
+		return err
+	}
+{{- else}}
+	if err := obj_diff.DiffValuesAt(cs, append(path[:len(path):len(path)], helpers.NewFieldElem({{.Index}}, "{{.Name}}")),
+		reflect.ValueOf(&a.{{.Name}}).Elem(), reflect.ValueOf(&b.{{.Name}}).Elem(), obj_diff.DiffOptions{}); err != nil {
+		return err
+	}
+{{- end}}
+{{- end}}
+	return nil
+{{- end}}
+}
+
+// Copy{{.Name}} returns a deep copy of in, the same as obj_diff.CopyValueReflectively.
+func Copy{{.Name}}(in {{.Name}}) {{.Name}} {
+{{- if .CopyReflectively}}
+	return obj_diff.CopyValueReflectively(in).({{.Name}})
+{{- else}}
+	var out {{.Name}}
+{{- range .Fields}}
+{{- if .IsBasic}}
+	out.{{.Name}} = in.{{.Name}}
+{{- else if .IsStruct}}
+	out.{{.Name}} = Copy{{.Type}}(in.{{.Name}})
+{{- else}}
+	reflect.ValueOf(&out.{{.Name}}).Elem().Set(obj_diff.CopyReflectValue(reflect.ValueOf(&in.{{.Name}}).Elem()))
+{{- end}}
+{{- end}}
+	return out
+{{- end}}
+}
+
+// Apply{{.Name}} patches obj with the changes in cs, the same as cs.Patch(obj).
+func Apply{{.Name}}(obj *{{.Name}}, cs obj_diff.ChangeSet) error {
+	if obj == nil || cs.BaseType != reflect.TypeOf((*{{.Name}})(nil)).Elem() || !apply{{.Name}}Fields(obj, cs.Changes, false) {
+		return cs.Patch(obj)
+	}
+	apply{{.Name}}Fields(obj, cs.Changes, true)
+	return nil
+}
+
+// Sets the basic fields of obj from changes if set is true. Returns false,
+// without setting anything, if any change must be applied reflectively.
+func apply{{.Name}}Fields(obj *{{.Name}}, changes []helpers.Change, set bool) bool {
+	for _, change := range changes {
+		path := change.GetPath()
+		if len(path) != 1 || change.IsDeletion() || !change.GetNewValue().IsValid() {
+			return false
+		}
+		switch path[0].GetName() {
+{{- range .SettableFields}}
+		case "{{.Name}}":
+			value, ok := change.GetNewValue().Interface().({{.Type}})
+			if !ok {
+				return false
+			}
+			if set {
+				obj.{{.Name}} = value
+			}
+{{- end}}
+		default:
+			return false
+		}
+	}
+	return true
+}
+{{end}}`))
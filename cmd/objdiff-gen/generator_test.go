@@ -0,0 +1,51 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The generated code in gentest is checked against the reflective engine by
+// its own tests, this makes sure it is up to date.
+func TestGenerateGentest(t *testing.T) {
+	dir := filepath.Join("..", "..", "pkg", "obj_diff", "gentest")
+	expect, err := ioutil.ReadFile(filepath.Join(dir, defaultOutput))
+	if err != nil {
+		t.Fatalf("Error reading %v: %v", defaultOutput, err)
+	}
+
+	actual, err := Generate(dir, defaultOutput)
+	if err != nil {
+		t.Fatalf("Error in Generate: %v", err)
+	}
+	if string(expect) != string(actual) {
+		t.Errorf("%v is out of date, run go generate", filepath.Join(dir, defaultOutput))
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "objdiff-gen")
+	if err != nil {
+		t.Fatalf("Error in TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	sources := map[string]string{
+		"No markers": "package a\n\ntype A struct{}\n",
+		"Not struct": "package a\n\n// +objdiff:gen\ntype A int\n",
+	}
+	for name, src := range sources {
+		if err := ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0644); err != nil {
+			t.Fatalf("Error in WriteFile: %v", err)
+		}
+		if _, err := Generate(dir, defaultOutput); err == nil {
+			t.Errorf("Expected an error for %v", name)
+		}
+	}
+}
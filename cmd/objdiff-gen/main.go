@@ -0,0 +1,47 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Command objdiff-gen generates typed Diff, Copy and Apply functions for the
+// struct types in a package marked with a "+objdiff:gen" comment:
+//
+//	// +objdiff:gen
+//	type Widget struct {
+//		...
+//	}
+//
+// It is intended to be run by go generate from within the package:
+//
+//	//go:generate objdiff-gen
+//
+// For each marked type X it writes DiffX, CopyX and ApplyX to
+// zz_generated.objdiff.go. The generated functions produce the same results
+// as obj_diff.Diff, obj_diff.CopyValueReflectively and ChangeSet.Patch,
+// falling back to them for the values they do not handle statically.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "the directory of the package to generate for")
+	output := flag.String("output", defaultOutput, "the name of the generated file within dir")
+	flag.Parse()
+
+	src, err := Generate(*dir, *output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "objdiff-gen: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(*dir, *output), src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "objdiff-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
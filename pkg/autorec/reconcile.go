@@ -259,12 +259,6 @@ func setServerCheckpoint(cm ClientManager, serverActual Replacer) error {
 }
 
 func buildServerCheckpoint(serverActual Replacer) ([]byte, error) {
-	type JsonPatchAdd struct {
-		Op    string `json:"op"`
-		Path  string `json:"path"`
-		Value string `json:"value"`
-	}
-
 	annotations := serverActual.GetAnnotations()
 	if annotations == nil {
 		annotations = map[string]string{}
@@ -282,8 +276,13 @@ func buildServerCheckpoint(serverActual Replacer) ([]byte, error) {
 		return nil, err
 	}
 
+	serverCheckpointValue, err := json.Marshal(string(serverCheckpointBytes))
+	if err != nil {
+		return nil, err
+	}
+
 	path := "/metadata/annotations/" + string(ServerSide)
-	patchObj := []JsonPatchAdd{{Op: "add", Path: path, Value: string(serverCheckpointBytes)}}
+	patchObj := []obj_diff.JSONPatchOperation{{Op: obj_diff.JSONPatchAdd, Path: path, Value: serverCheckpointValue}}
 	patchBytes, err := json.Marshal(patchObj)
 	if err != nil {
 		return nil, err
@@ -36,80 +36,238 @@ func (cs *ChangeSet) AddPathDeletion(ctx []PathElement, oldValue reflect.Value)
 	cs.Changes = append(cs.Changes, NewValueDeletion(ctx, oldValue))
 }
 
+// Options for patching an object with a ChangeSet.
+type PatchOptions struct {
+	// Controls whether missing maps, slices, pointers and values along each
+	// path are created.
+	ObjectPathConfig
+	// What to do with a Change whose path can not be followed, such as a
+	// missing map key, a nil pointer or an index out of range.
+	OnMissingParent MissingParentPolicy
+	// Apply every Change or none of them, see PatchAtomic.
+	Atomic bool
+	// Set the values held by each Change directly rather than copies. This is
+	// faster, but maps, slices and pointers in the patched object then alias
+	// the ChangeSet and every other object it is applied to.
+	ShareValues bool
+}
+
+// What to do with a Change whose path can not be followed.
+type MissingParentPolicy int
+
+const (
+	// Stop patching and return a PathNotFoundError or IndexOutOfRangeError.
+	FailOnMissingParent MissingParentPolicy = iota
+	// Skip the Change and continue with the next one.
+	SkipOnMissingParent
+)
+
+// Returns the options used by Patch, missing objects and values are created.
+func DefaultPatchOptions() PatchOptions {
+	return PatchOptions{ObjectPathConfig: ObjectPathConfig{true, true}}
+}
+
 // Patch an object (in place/by reference) with the Changes within this
-// ChangeSet. Panics if obj is not settable or does not match the BaseType.
-func (cs ChangeSet) Patch(obj interface{}) (err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			e, ok := r.(PatchError)
-			if ok {
-				// fmt.Println("Recovered in Patch", r)
-				err = e
-			} else {
-				panic(r)
-			}
+// ChangeSet. Returns an error if obj is not settable or does not match the
+// BaseType. If a change fails part way through, any changes before it will
+// already have been applied to obj; see PatchAtomic.
+func (cs ChangeSet) Patch(obj interface{}) error {
+	return cs.PatchWithOptions(obj, DefaultPatchOptions())
+}
+
+// Patch an object (in place/by reference) with the Changes within this
+// ChangeSet as a single transaction. The changes are applied to a copy of obj
+// and only written back once every change has succeeded, if any change fails
+// obj is left exactly as it was.
+func (cs ChangeSet) PatchAtomic(obj interface{}) error {
+	opts := DefaultPatchOptions()
+	opts.Atomic = true
+	return cs.PatchWithOptions(obj, opts)
+}
+
+// Patch an object (in place/by reference) with the Changes within this
+// ChangeSet, as controlled by opts.
+func (cs ChangeSet) PatchWithOptions(obj interface{}, opts PatchOptions) error {
+	root, err := cs.patchRoot(obj)
+	if err != nil {
+		return err
+	}
+
+	if !opts.Atomic {
+		return cs.applyChangesWithOptions(root, opts)
+	}
+
+	// The working copy is placed behind a new pointer so that it is settable
+	// in the same way as the object we were given.
+	working := reflect.New(root.Type()).Elem()
+	working.Set(CopyReflectValue(root))
+	if err := cs.applyChangesWithOptions(working, opts); err != nil {
+		return err
+	}
+
+	if root.CanSet() {
+		root.Set(working)
+	} else if working.IsNil() {
+		return NewPatchError("can not delete the object passed to PatchAtomic")
+	} else {
+		// root is a pointer matching the BaseType, commit to what it points at.
+		root.Elem().Set(working.Elem())
+	}
+	return nil
+}
+
+// Apply the Changes within this ChangeSet to a copy of obj and return the
+// patched copy, obj itself is never modified. The obj may be a value of the
+// BaseType or a pointer to one, the result has the same type as obj.
+func (cs ChangeSet) Apply(obj interface{}) (interface{}, error) {
+	objVal := reflect.ValueOf(obj)
+	if !objVal.IsValid() {
+		return nil, NewPatchError("can not apply to nil when expecting type %v", cs.BaseType)
+	}
+
+	// Pointers to the BaseType are dereferenced so the copy is patched in the
+	// same way Patch would treat them.
+	isPtr := objVal.Type() != cs.BaseType && objVal.Kind() == reflect.Ptr && objVal.Type().Elem() == cs.BaseType
+	if isPtr {
+		if objVal.IsNil() {
+			return nil, NewPatchError("can not apply to a nil %v", objVal.Type())
 		}
-	}()
+		objVal = objVal.Elem()
+	} else if objVal.Type() != cs.BaseType {
+		return nil, TypeMismatchError{Path: []PathElement{}, ChangeIndex: -1, Expected: cs.BaseType, Actual: objVal.Type()}
+	}
 
+	working := reflect.New(cs.BaseType)
+	working.Elem().Set(CopyReflectValue(objVal))
+	if err := cs.applyChanges(working.Elem()); err != nil {
+		return nil, err
+	}
+
+	if isPtr {
+		return working.Interface(), nil
+	}
+	return working.Elem().Interface(), nil
+}
+
+// Validate obj as a patch target and return the value the change paths
+// should be applied from.
+func (cs ChangeSet) patchRoot(obj interface{}) (reflect.Value, error) {
 	root := reflect.ValueOf(obj)
-	if root.Kind() != reflect.Ptr || !root.Elem().CanSet() {
-		return NewPatchError("can not set obj1 of Type: %v", root.Type())
+	if root.Kind() != reflect.Ptr || root.IsNil() || !root.Elem().CanSet() {
+		return reflect.Value{}, NewPatchError("can not set obj of Type: %T", obj)
 	}
 
 	if root.Type() != cs.BaseType {
 		if root.Elem().Type() == cs.BaseType {
 			root = root.Elem()
 		} else {
-			return NewPatchError("obj (%v) is not of type %v", reflect.TypeOf(obj), cs.BaseType)
+			return reflect.Value{}, TypeMismatchError{Path: []PathElement{}, ChangeIndex: -1, Expected: cs.BaseType, Actual: root.Type()}
 		}
 	}
 
+	return root, nil
+}
 
-	opConfig := ObjectPathConfig{true, true}
+// Apply each of the Changes in order to root, creating any missing objects
+// and values along the way.
+func (cs ChangeSet) applyChanges(root reflect.Value) error {
+	return cs.applyChangesWithOptions(root, DefaultPatchOptions())
+}
 
+// Apply each of the Changes in order to root. The Atomic option is handled
+// by the callers as they own the object being patched.
+func (cs ChangeSet) applyChangesWithOptions(root reflect.Value, opts PatchOptions) error {
 	for i := 0; i < len(cs.Changes); i++ {
-		// fmt.Println()
-		// fmt.Printf("##################\n")
-		// fmt.Printf("### New Change ###\n")
-		// fmt.Printf("##################\n")
-		// fmt.Printf("Root: %+v\n", root)
-		change := cs.Changes[i]
-		// fmt.Printf("Change: %+v\n", change)
-		op := NewObjectPathWithConfig(root, change.GetPath(), opConfig)
-		// The first call to op.Next() skips past the pointer we were passed. If we
-		// want to do anything with that pointer beforehand we must do it here.
-		for op.Next() {
-			// This loop is primarily ornamental, the call above to op.Next()
-			// traverses the path, but there is nothing to do as the ObjectPath
-			// takes care of everything. This is here primarily to be an extension
-			// point for future changes.
-			// fmt.Printf("Types lastVal: %T, currVal: %T\n", op.LastVal().Interface(), op.Interface())
-			// fmt.Printf("Kinds lastVal: %v, currVal: %v\n", op.LastVal().Kind(), op.Kind())
-			// fmt.Println("==================")
-
-			switch op.Kind() {
-			case reflect.Struct:
-				// NO-OP
-			case reflect.Map:
-				// NO-OP
-			case reflect.Array:
-				// NO-OP
-			case reflect.Slice:
-				// NO-OP
-			case reflect.Ptr:
-				// NO-OP
+		err := applyChange(root, cs.Changes[i], opts)
+		if err != nil {
+			err = withChangeIndex(err, i)
+			if opts.OnMissingParent == SkipOnMissingParent && isMissingParent(err) {
+				continue
 			}
+			return err
 		}
+	}
 
-		// Once we are at the end of the path we
-		// either delete or update a value.
-		if change.IsDeletion() {
-			op.Delete()
-		} else {
-			op.Set(change.GetNewValue())
+	return nil
+}
+
+// Apply a single Change to root.
+func applyChange(root reflect.Value, change Change, opts PatchOptions) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			// fmt.Println("Recovered in Patch", r)
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				// Such as the panics from reflect when given bad input.
+				err = NewPatchError("%v", r)
+			}
+		}
+	}()
+
+	// fmt.Println()
+	// fmt.Printf("##################\n")
+	// fmt.Printf("### New Change ###\n")
+	// fmt.Printf("##################\n")
+	// fmt.Printf("Root: %+v\n", root)
+	// fmt.Printf("Change: %+v\n", change)
+	path := change.GetPath()
+	newValue := change.GetNewValue()
+	if !opts.ShareValues {
+		newValue = CopyReflectValue(newValue)
+	}
+
+	if change.IsAddition() && len(path) > 0 {
+		// Additions to a Slice are inserted at their index, so we stop at
+		// the Slice rather than its element.
+		parent := NewObjectPathWithConfig(root, path[:len(path)-1], opts.ObjectPathConfig)
+		for len(parent.Path) > 0 && parent.Next() {
+			// Traversal only, see below.
+		}
+		if parent.Kind() == reflect.Slice {
+			parent.Insert(path[len(path)-1].GetIndex(), newValue)
+			return nil
+		}
+	}
+
+	op := NewObjectPathWithConfig(root, path, opts.ObjectPathConfig)
+	// The first call to op.Next() skips past the pointer we were passed. If we
+	// want to do anything with that pointer beforehand we must do it here. An
+	// empty path refers to root itself so there is nothing to traverse.
+	for len(change.GetPath()) > 0 && op.Next() {
+		// This loop is primarily ornamental, the call above to op.Next()
+		// traverses the path, but there is nothing to do as the ObjectPath
+		// takes care of everything. This is here primarily to be an extension
+		// point for future changes.
+		// fmt.Printf("Types lastVal: %T, currVal: %T\n", op.LastVal().Interface(), op.Interface())
+		// fmt.Printf("Kinds lastVal: %v, currVal: %v\n", op.LastVal().Kind(), op.Kind())
+		// fmt.Println("==================")
+
+		switch op.Kind() {
+		case reflect.Struct:
+			// NO-OP
+		case reflect.Map:
+			// NO-OP
+		case reflect.Array:
+			// NO-OP
+		case reflect.Slice:
+			// NO-OP
+		case reflect.Ptr:
+			// NO-OP
 		}
 	}
 
+	// Once we are at the end of the path we
+	// either delete or update a value.
+	if change.IsDeletion() {
+		op.Delete()
+	} else if newValue.IsValid() {
+		op.Set(newValue)
+	} else {
+		// A nil new value, such as an Interface being cleared.
+		op.Set(reflect.Zero(op.Type()))
+	}
+
 	return nil
 }
 
@@ -137,3 +295,33 @@ func (cs ChangeSet) Equals(other ChangeSet) bool {
 
 	return true
 }
+
+// Compares this ChangeSet against another ChangeSet ignoring the order of
+// the Changes, returns true if both contain the same Changes.
+func (cs ChangeSet) EqualsUnordered(other ChangeSet) bool {
+	if cs.BaseType != other.BaseType {
+		return false
+	}
+
+	if len(cs.Changes) != len(other.Changes) {
+		return false
+	}
+
+	matched := make([]bool, len(other.Changes))
+	for _, c1 := range cs.Changes {
+		found := false
+		for j, c2 := range other.Changes {
+			if !matched[j] && c1.Equals(c2) {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
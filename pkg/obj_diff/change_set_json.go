@@ -0,0 +1,344 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+)
+
+// The version of the JSON encoding produced by ChangeSet.MarshalJSON.
+// Version 1, which recorded types without their package path, can still be
+// decoded.
+const ChangeSetJSONVersion = 2
+
+const (
+	jsonOpChange = "change"
+	jsonOpAdd    = "add"
+	jsonOpDelete = "delete"
+)
+
+type jsonChangeSet struct {
+	Version  int          `json:"version"`
+	BaseType string       `json:"baseType"`
+	Changes  []jsonChange `json:"changes"`
+}
+
+type jsonChange struct {
+	Op        string            `json:"op"`
+	Path      []jsonPathElement `json:"path"`
+	ValueType string            `json:"valueType,omitempty"`
+	// Set if the old value is of a different type, as it can be when held in
+	// an interface.
+	OldValueType string          `json:"oldValueType,omitempty"`
+	Old          json.RawMessage `json:"old,omitempty"`
+	New          json.RawMessage `json:"new,omitempty"`
+}
+
+type jsonPathElement struct {
+	Field   string          `json:"field,omitempty"`
+	Index   *int            `json:"index,omitempty"`
+	Key     json.RawMessage `json:"key,omitempty"`
+	KeyType string          `json:"keyType,omitempty"`
+	Pointer bool            `json:"pointer,omitempty"`
+}
+
+// Encodes this ChangeSet as versioned JSON. Struct fields are recorded by
+// name and index, map keys and values are recorded along with their types.
+// Values are encoded with encoding/json, so anything it can not round-trip
+// (such as unexported struct fields) will be lost. Values held in an
+// interface must be of a type listed in interfaceValueTypes, or an error is
+// returned, as the type could not be decoded.
+func (cs ChangeSet) MarshalJSON() ([]byte, error) {
+	if cs.BaseType == nil {
+		return nil, fmt.Errorf("can not encode a ChangeSet without a BaseType")
+	}
+
+	encoded := jsonChangeSet{Version: ChangeSetJSONVersion, BaseType: encodedTypeName(cs.BaseType, ChangeSetJSONVersion), Changes: []jsonChange{}}
+	for _, change := range cs.Changes {
+		jc, err := encodeChange(cs.BaseType, change)
+		if err != nil {
+			return nil, err
+		}
+		encoded.Changes = append(encoded.Changes, jc)
+	}
+
+	return json.Marshal(encoded)
+}
+
+// Decodes a ChangeSet produced by MarshalJSON. The BaseType of cs must
+// already be set as it is used to decode the paths and values.
+func (cs *ChangeSet) UnmarshalJSON(data []byte) error {
+	if cs.BaseType == nil {
+		return fmt.Errorf("a BaseType is required to decode a ChangeSet")
+	}
+
+	var encoded jsonChangeSet
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+
+	if encoded.Version < 1 || encoded.Version > ChangeSetJSONVersion {
+		return fmt.Errorf("unsupported ChangeSet version %v", encoded.Version)
+	}
+	if encoded.BaseType != encodedTypeName(cs.BaseType, encoded.Version) {
+		return fmt.Errorf("ChangeSet for %v can not be decoded as %v", encoded.BaseType, cs.BaseType)
+	}
+
+	changes := []Change{}
+	for i, jc := range encoded.Changes {
+		change, err := decodeChange(cs.BaseType, jc, encoded.Version)
+		if err != nil {
+			return fmt.Errorf("change %v: %w", i, err)
+		}
+		changes = append(changes, change)
+	}
+
+	cs.Changes = changes
+	return nil
+}
+
+// Decodes a ChangeSet produced by MarshalJSON for the given baseType.
+func UnmarshalChangeSet(data []byte, baseType reflect.Type) (*ChangeSet, error) {
+	cs := &ChangeSet{BaseType: baseType}
+	if err := json.Unmarshal(data, cs); err != nil {
+		return nil, err
+	}
+
+	return cs, nil
+}
+
+// Returns the name recorded for typ by the given version of the encoding.
+// Named types are qualified by their package path, so types with the same
+// name in different packages are told apart.
+func encodedTypeName(typ reflect.Type, version int) string {
+	if version < 2 {
+		return typ.String()
+	}
+
+	if len(typ.Name()) > 0 {
+		if len(typ.PkgPath()) == 0 {
+			return typ.Name()
+		}
+		return typ.PkgPath() + "." + typ.Name()
+	}
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		return "*" + encodedTypeName(typ.Elem(), version)
+	case reflect.Slice:
+		return "[]" + encodedTypeName(typ.Elem(), version)
+	case reflect.Array:
+		return fmt.Sprintf("[%v]%v", typ.Len(), encodedTypeName(typ.Elem(), version))
+	case reflect.Map:
+		return fmt.Sprintf("map[%v]%v", encodedTypeName(typ.Key(), version), encodedTypeName(typ.Elem(), version))
+	}
+	return typ.String()
+}
+
+// The types which can be decoded from a value held in an interface, these
+// are the types encoding/json decodes to along with the basic types.
+var interfaceValueTypes = map[string]reflect.Type{}
+
+func init() {
+	values := []interface{}{
+		false, "", int(0), int8(0), int16(0), int32(0), int64(0),
+		uint(0), uint8(0), uint16(0), uint32(0), uint64(0), uintptr(0), float32(0), float64(0),
+		map[string]interface{}{}, []interface{}{},
+	}
+	for _, value := range values {
+		typ := reflect.TypeOf(value)
+		interfaceValueTypes[encodedTypeName(typ, ChangeSetJSONVersion)] = typ
+	}
+}
+
+// Returns an error if value is held in a slot of slotType which is an
+// interface and its type can not be decoded.
+func checkInterfaceValue(slotType reflect.Type, value reflect.Value) error {
+	if slotType.Kind() != reflect.Interface {
+		return nil
+	}
+	if _, ok := interfaceValueTypes[encodedTypeName(value.Type(), ChangeSetJSONVersion)]; !ok {
+		return fmt.Errorf("can not encode a %v held in %v", value.Type(), slotType)
+	}
+	return nil
+}
+
+func encodeChange(baseType reflect.Type, change Change) (jc jsonChange, err error) {
+	currType := baseType
+	for _, pe := range change.GetPath() {
+		if key := pe.GetKey(); key.IsValid() && currType.Kind() == reflect.Map {
+			if err := checkInterfaceValue(currType.Key(), key); err != nil {
+				return jc, err
+			}
+		}
+		jpe, err := encodePathElement(pe)
+		if err != nil {
+			return jc, err
+		}
+		jc.Path = append(jc.Path, jpe)
+
+		if currType, err = typeAtPath(currType, []PathElement{pe}); err != nil {
+			return jc, err
+		}
+	}
+
+	switch {
+	case change.IsDeletion():
+		jc.Op = jsonOpDelete
+	case change.IsAddition():
+		jc.Op = jsonOpAdd
+	default:
+		jc.Op = jsonOpChange
+	}
+
+	if oldValue := change.GetOldValue(); oldValue.IsValid() {
+		if err := checkInterfaceValue(currType, oldValue); err != nil {
+			return jc, err
+		}
+		jc.ValueType = encodedTypeName(oldValue.Type(), ChangeSetJSONVersion)
+		if jc.Old, err = json.Marshal(oldValue.Interface()); err != nil {
+			return jc, err
+		}
+	}
+
+	if newValue := change.GetNewValue(); newValue.IsValid() {
+		if err := checkInterfaceValue(currType, newValue); err != nil {
+			return jc, err
+		}
+		newType := encodedTypeName(newValue.Type(), ChangeSetJSONVersion)
+		if len(jc.ValueType) > 0 && jc.ValueType != newType {
+			jc.OldValueType = jc.ValueType
+		}
+		jc.ValueType = newType
+		if jc.New, err = json.Marshal(newValue.Interface()); err != nil {
+			return jc, err
+		}
+	}
+
+	return jc, nil
+}
+
+func encodePathElement(pe PathElement) (jpe jsonPathElement, err error) {
+	if key := pe.GetKey(); key.IsValid() {
+		jpe.KeyType = encodedTypeName(key.Type(), ChangeSetJSONVersion)
+		jpe.Key, err = json.Marshal(key.Interface())
+		return
+	}
+
+	if pe.IsPointer() {
+		jpe.Pointer = true
+		return
+	}
+
+	index := pe.GetIndex()
+	jpe.Field = pe.GetName()
+	jpe.Index = &index
+	return
+}
+
+func decodeChange(baseType reflect.Type, jc jsonChange, version int) (Change, error) {
+	path := []PathElement{}
+	currType := baseType
+	for _, jpe := range jc.Path {
+		pe, nextType, err := decodePathElement(currType, jpe, version)
+		if err != nil {
+			return nil, fmt.Errorf("at %v: %w", pathString(path), err)
+		}
+		path = append(path, pe)
+		currType = nextType
+	}
+
+	oldType := jc.ValueType
+	if len(jc.OldValueType) > 0 {
+		oldType = jc.OldValueType
+	}
+	oldValue, err := decodeValue(currType, oldType, jc.Old, version)
+	if err != nil {
+		return nil, err
+	}
+	newValue, err := decodeValue(currType, jc.ValueType, jc.New, version)
+	if err != nil {
+		return nil, err
+	}
+
+	switch jc.Op {
+	case jsonOpDelete:
+		return NewValueDeletion(path, oldValue), nil
+	case jsonOpAdd:
+		return NewValueAddition(path, newValue), nil
+	case jsonOpChange:
+		return NewValueChange(path, oldValue, newValue), nil
+	}
+
+	return nil, fmt.Errorf("unknown op %q", jc.Op)
+}
+
+// Decodes jpe as a step from currType, returning the PathElement and the
+// type it leads to.
+func decodePathElement(currType reflect.Type, jpe jsonPathElement, version int) (PathElement, reflect.Type, error) {
+	switch currType.Kind() {
+	case reflect.Struct:
+		if jpe.Index == nil || len(jpe.Field) == 0 {
+			return nil, nil, fmt.Errorf("expected a field of %v", currType)
+		}
+		field, ok := currType.FieldByName(jpe.Field)
+		if !ok || len(field.Index) != 1 {
+			return nil, nil, fmt.Errorf("no field %v in %v", jpe.Field, currType)
+		}
+		return NewFieldElem(field.Index[0], field.Name), field.Type, nil
+
+	case reflect.Map:
+		if jpe.Key == nil {
+			return nil, nil, fmt.Errorf("expected a key of %v", currType)
+		}
+		key, err := decodeValue(currType.Key(), jpe.KeyType, jpe.Key, version)
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewKeyElem(key), currType.Elem(), nil
+
+	case reflect.Array, reflect.Slice:
+		if jpe.Index == nil || len(jpe.Field) > 0 {
+			return nil, nil, fmt.Errorf("expected an index of %v", currType)
+		}
+		return NewIndexElem(*jpe.Index), currType.Elem(), nil
+
+	case reflect.Ptr:
+		if !jpe.Pointer {
+			return nil, nil, fmt.Errorf("expected a pointer for %v", currType)
+		}
+		return NewPtrElem(), currType.Elem(), nil
+	}
+
+	return nil, nil, fmt.Errorf("can not traverse %v", currType)
+}
+
+// Decodes raw into a new value of valueType, returns an invalid value if
+// there is nothing to decode. A value held in an interface is decoded as
+// the type named by typeName, just as Diff would have captured it.
+func decodeValue(valueType reflect.Type, typeName string, raw json.RawMessage, version int) (reflect.Value, error) {
+	if raw == nil {
+		return reflect.Value{}, nil
+	}
+
+	if valueType.Kind() == reflect.Interface {
+		concrete, ok := interfaceValueTypes[typeName]
+		if !ok || !concrete.Implements(valueType) {
+			return reflect.Value{}, fmt.Errorf("can not decode a %v held in %v", typeName, valueType)
+		}
+		valueType = concrete
+	} else if typeName != encodedTypeName(valueType, version) {
+		return reflect.Value{}, fmt.Errorf("encoded type %v does not match %v", typeName, valueType)
+	}
+
+	value := reflect.New(valueType)
+	if err := json.Unmarshal(raw, value.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return value.Elem(), nil
+}
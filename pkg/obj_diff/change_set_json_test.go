@@ -0,0 +1,188 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestChangeSetJSON(t *testing.T) {
+	four := int16(4)
+	five := int16(5)
+	nest1 := NestObj{9, "A"}
+	nest2 := NestObj{9, "B"}
+	nest3 := NestObj{8, "C"}
+	o1 := Obj{1, &four, 1.2, "Foo", false,
+		[]int64{1, 2}, [3]bool{true, false, true},
+		map[string]int64{"a": 1, "b": 2, "d": 4},
+		NestObj{3, "Hello"}, &nest1, nil, &nest2,
+		map[string]map[string]NestObj{"a": {"b": nest1}, "c": {"d": nest2}}, Obj{}.Quantity}
+	o2 := Obj{2, &five, 3.14, "Bar", true,
+		[]int64{3, 4, 5}, [3]bool{true, true, false},
+		map[string]int64{"a": 2, "c": 3, "d": 4},
+		NestObj{7, "World"}, &nest2, &nest1, nil,
+		map[string]map[string]NestObj{"a": {"b": nest3}, "c": {"d": nest2}, "e": {}}, Obj{}.Quantity}
+
+	tests := []struct {
+		name   string
+		base   interface{}
+		update interface{}
+	}{
+		{name: "Struct", base: o1, update: o2},
+		{name: "Struct Pointer", base: &o1, update: &o2},
+		{name: "Int Keys", base: map[int]string{1: "a", 2: "b"}, update: map[int]string{2: "c", 3: "d"}},
+		{name: "Basic", base: "Hello", update: "World"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expect, err := Diff(test.base, test.update)
+			if err != nil {
+				t.Fatalf("Error in Diff: %v", err)
+			}
+
+			data, err := json.Marshal(expect)
+			if err != nil {
+				t.Fatalf("Error in Marshal: %v", err)
+			}
+
+			actual, err := UnmarshalChangeSet(data, reflect.TypeOf(test.base))
+			if err != nil {
+				t.Fatalf("Error in UnmarshalChangeSet: %v", err)
+			}
+
+			if !expect.Equals(*actual) {
+				t.Logf("JSON: %s", data)
+				t.Logf("Expect: %+v", expect)
+				t.Logf("Actual: %+v", actual)
+				t.Fail()
+			}
+
+			patched, err := actual.Apply(test.base)
+			if err != nil {
+				t.Fatalf("Error in Apply: %v", err)
+			}
+			if !reflect.DeepEqual(test.update, patched) {
+				t.Logf("Expect: %+v", test.update)
+				t.Logf("Actual: %+v", patched)
+				t.Fail()
+			}
+
+			yamlData, err := expect.ToYAML()
+			if err != nil {
+				t.Fatalf("Error in ToYAML: %v", err)
+			}
+			fromYAML, err := UnmarshalChangeSetYAML(yamlData, reflect.TypeOf(test.base))
+			if err != nil {
+				t.Fatalf("Error in UnmarshalChangeSetYAML: %v", err)
+			}
+			if !expect.Equals(*fromYAML) {
+				t.Logf("YAML: %s", yamlData)
+				t.Logf("Expect: %+v", expect)
+				t.Logf("Actual: %+v", fromYAML)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestChangeSetJSONErrors(t *testing.T) {
+	diff, err := Diff(map[string]int32{"a": 1}, map[string]int32{"a": 2})
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		t.Fatalf("Error in Marshal: %v", err)
+	}
+
+	if _, err := UnmarshalChangeSet(data, reflect.TypeOf(map[string]int64{})); err == nil {
+		t.Errorf("Expected an error decoding as the wrong type")
+	}
+
+	var cs ChangeSet
+	if err := json.Unmarshal(data, &cs); err == nil {
+		t.Errorf("Expected an error decoding without a BaseType")
+	}
+
+	if _, err := UnmarshalChangeSet([]byte(`{"version": 99}`), diff.BaseType); err == nil {
+		t.Errorf("Expected an error decoding an unknown version")
+	}
+}
+
+type jsonInterfaceObject struct {
+	Any    interface{}
+	Values map[string]interface{}
+	Keys   map[interface{}]string
+}
+
+func TestChangeSetJSONInterfaces(t *testing.T) {
+	base := jsonInterfaceObject{Any: int(1), Values: map[string]interface{}{"a": int32(1)}, Keys: map[interface{}]string{uint8(1): "a"}}
+	update := jsonInterfaceObject{Any: int(2), Values: map[string]interface{}{"a": "b", "c": []interface{}{1.5}}, Keys: map[interface{}]string{uint8(1): "b"}}
+	expect, err := Diff(base, update)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	data, err := json.Marshal(expect)
+	if err != nil {
+		t.Fatalf("Error in Marshal: %v", err)
+	}
+	actual, err := UnmarshalChangeSet(data, expect.BaseType)
+	if err != nil {
+		t.Fatalf("Error in UnmarshalChangeSet: %v", err)
+	}
+	if !expect.Equals(*actual) || !reflect.DeepEqual(expect, actual) {
+		t.Logf("JSON: %s", data)
+		t.Logf("Expect: %+v", expect)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+
+	// Only types which can be decoded may be held in an interface.
+	unencodable, err := Diff(jsonInterfaceObject{Any: NestObj{}}, jsonInterfaceObject{Any: NestObj{Int: 1}})
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+	if _, err := json.Marshal(unencodable); err == nil {
+		t.Errorf("Expected an error encoding a struct held in an interface")
+	}
+}
+
+func TestChangeSetJSONBaseType(t *testing.T) {
+	diff, err := Diff(NestObj{Int: 1}, NestObj{Int: 2})
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		t.Fatalf("Error in Marshal: %v", err)
+	}
+	var encoded jsonChangeSet
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		t.Fatalf("Error in Unmarshal: %v", err)
+	}
+	if expect := "github.com/walmartlabs/object-diff/pkg/obj_diff.NestObj"; expect != encoded.BaseType {
+		t.Logf("Expect: %v", expect)
+		t.Logf("Actual: %v", encoded.BaseType)
+		t.Fail()
+	}
+
+	// Version 1 recorded unqualified types.
+	v1 := []byte(`{"version":1,"baseType":"obj_diff.NestObj","changes":[` +
+		`{"op":"change","path":[{"field":"Int","index":0}],"valueType":"int64","old":1,"new":2}]}`)
+	decoded, err := UnmarshalChangeSet(v1, diff.BaseType)
+	if err != nil {
+		t.Fatalf("Error in UnmarshalChangeSet: %v", err)
+	}
+	if !diff.Equals(*decoded) {
+		t.Logf("Expect: %+v", diff)
+		t.Logf("Actual: %+v", decoded)
+		t.Fail()
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"fmt"
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+)
+
+// Returns a new ChangeSet, with the same BaseType, containing only the
+// Changes for which keep returns true.
+func (cs ChangeSet) Filter(keep func(Change) bool) ChangeSet {
+	filtered := ChangeSet{BaseType: cs.BaseType}
+	for _, change := range cs.Changes {
+		if keep(change) {
+			filtered.Changes = append(filtered.Changes, change)
+		}
+	}
+
+	return filtered
+}
+
+// Returns a new ChangeSet containing only the Changes at or below prefix.
+func (cs ChangeSet) FilterPrefix(prefix []PathElement) ChangeSet {
+	return cs.Filter(func(change Change) bool {
+		return hasPathPrefix(change.GetPath(), prefix)
+	})
+}
+
+// Returns a new ChangeSet containing only the Changes whose path matches
+// pattern, see PathPattern for the syntax.
+func (cs ChangeSet) FilterPattern(pattern string) (ChangeSet, error) {
+	pp, err := NewPathPattern(pattern)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+
+	return cs.Filter(func(change Change) bool {
+		return pp.Match(change.GetPath())
+	}), nil
+}
+
+// Splits this ChangeSet into sub-ChangeSets by the top-level element of each
+// Change, leading pointer steps are skipped. Struct fields are keyed by their
+// name and other elements by their String(), changes to the whole object are
+// keyed by "". Each sub-ChangeSet keeps the BaseType so it can still be
+// patched onto the whole object, or passed to Rebase.
+func (cs ChangeSet) Partition() map[string]ChangeSet {
+	partitions := map[string]ChangeSet{}
+	for _, change := range cs.Changes {
+		key := ""
+		for _, pe := range change.GetPath() {
+			if pe.IsPointer() {
+				continue
+			}
+
+			if len(pe.GetName()) > 0 {
+				key = pe.GetName()
+			} else {
+				key = pe.String()
+			}
+			break
+		}
+
+		partition, ok := partitions[key]
+		if !ok {
+			partition = ChangeSet{BaseType: cs.BaseType}
+		}
+		partition.Changes = append(partition.Changes, change)
+		partitions[key] = partition
+	}
+
+	return partitions
+}
+
+// Returns a new ChangeSet whose paths are relative to prefix and whose
+// BaseType is the type found at prefix, the result can be used to patch
+// that subtree directly. Returns an error if any Change is not at or
+// below prefix.
+func (cs ChangeSet) Rebase(prefix []PathElement) (ChangeSet, error) {
+	baseType, err := typeAtPath(cs.BaseType, prefix)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+
+	rebased := ChangeSet{BaseType: baseType}
+	for i, change := range cs.Changes {
+		if !hasPathPrefix(change.GetPath(), prefix) {
+			return ChangeSet{}, PathNotFoundError{Path: change.GetPath(), ChangeIndex: i, Reason: fmt.Sprintf("change is not below %v", pathString(prefix))}
+		}
+
+		rebased.Changes = append(rebased.Changes, withPath(change, change.GetPath()[len(prefix):]))
+	}
+
+	return rebased, nil
+}
+
+// Returns true if path begins with each of the elements in prefix.
+func hasPathPrefix(path []PathElement, prefix []PathElement) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+
+	for i := range prefix {
+		if !prefix[i].Equals(path[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns true if the two paths have the same elements.
+func pathsEqual(p1 []PathElement, p2 []PathElement) bool {
+	return len(p1) == len(p2) && hasPathPrefix(p1, p2)
+}
+
+// Renders a path the same way as Change.PathString().
+func pathString(path []PathElement) string {
+	str := ""
+	for _, pe := range path {
+		str += pe.String()
+	}
+	return str
+}
+
+// Returns a copy of change at a new path.
+func withPath(change Change, path []PathElement) Change {
+	newPath := make([]PathElement, len(path))
+	copy(newPath, path)
+	if change.IsDeletion() {
+		return NewValueDeletion(newPath, change.GetOldValue())
+	} else if change.IsAddition() {
+		return NewValueAddition(newPath, change.GetNewValue())
+	}
+
+	return NewValueChange(newPath, change.GetOldValue(), change.GetNewValue())
+}
+
+// Find the type reached by following path from baseType.
+func typeAtPath(baseType reflect.Type, path []PathElement) (reflect.Type, error) {
+	if baseType == nil {
+		return nil, TypeMismatchError{Path: []PathElement{}, ChangeIndex: -1}
+	}
+
+	currType := baseType
+	for i, pe := range path {
+		at := path[:i+1]
+		switch currType.Kind() {
+		case reflect.Struct:
+			index, err := fieldIndex(currType, pe)
+			if err != nil {
+				return nil, PathNotFoundError{Path: at, ChangeIndex: -1, Reason: err.Error()}
+			}
+			currType = currType.Field(index).Type
+		case reflect.Map:
+			currType = currType.Elem()
+		case reflect.Array, reflect.Slice:
+			currType = currType.Elem()
+		case reflect.Ptr:
+			if !pe.IsPointer() {
+				return nil, PathNotFoundError{Path: at, ChangeIndex: -1, Reason: "expected a pointer step"}
+			}
+			currType = currType.Elem()
+		default:
+			return nil, UnsupportedKindError{Path: at, ChangeIndex: -1, Kind: currType.Kind(), Op: "traverse"}
+		}
+	}
+
+	return currType, nil
+}
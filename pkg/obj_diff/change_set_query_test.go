@@ -0,0 +1,131 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+	"testing"
+)
+
+func buildQueryObjects() (Obj, Obj) {
+	nest1 := NestObj{9, "A"}
+	nest2 := NestObj{9, "B"}
+	o1 := Obj{Int: 1, Str: "Foo",
+		StrIntMap: map[string]int64{"image": 1, "b": 2},
+		NestedObj: NestObj{3, "Hello"}, NestedPtr1: &nest1,
+		MapOfMaps: map[string]map[string]NestObj{"a": {"image": nest1}}}
+	o2 := Obj{Int: 2, Str: "Bar",
+		StrIntMap: map[string]int64{"image": 2, "b": 2},
+		NestedObj: NestObj{7, "World"}, NestedPtr1: &nest2,
+		MapOfMaps: map[string]map[string]NestObj{"a": {"image": nest2}}}
+	return o1, o2
+}
+
+func TestPathPattern(t *testing.T) {
+	path := []PathElement{NewPtrElem(), NewFieldElem(12, "MapOfMaps"), NewKeyElem("a"), NewKeyElem("image"), NewFieldElem(1, "Str")}
+	tests := []struct {
+		pattern string
+		match   bool
+	}{
+		{pattern: ".MapOfMaps{a}{image}.Str", match: true},
+		{pattern: "*.MapOfMaps(12){a}{image}.Str(1)", match: true},
+		{pattern: ".MapOfMaps(11){a}{image}.Str", match: false},
+		{pattern: ".MapOfMaps**", match: true},
+		{pattern: "**{image}**", match: true},
+		{pattern: "**{image}", match: false},
+		{pattern: "**{im*}.*", match: true},
+		{pattern: `.Map?fMaps{"a"}{*}.Str`, match: true},
+		{pattern: ".MapOfMaps[*]**", match: false},
+		{pattern: "**", match: true},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			pp, err := NewPathPattern(test.pattern)
+			if err != nil {
+				t.Fatalf("error in test: %v", err)
+			}
+
+			if pp.Match(path) != test.match {
+				t.Errorf("Expected Match(%v) to be %v", pathString(path), test.match)
+			}
+		})
+	}
+
+	for _, invalid := range []string{".", "{a", "[x]", ".A(1", "A"} {
+		if _, err := NewPathPattern(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestChangeSetFilter(t *testing.T) {
+	o1, o2 := buildQueryObjects()
+	diff, err := Diff(o1, o2)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	nested := diff.FilterPrefix([]PathElement{NewFieldElem(8, "NestedObj")})
+	if len(nested.Changes) != 2 {
+		t.Errorf("Expected 2 NestedObj changes, got: %v", nested.Changes)
+	}
+
+	images, err := diff.FilterPattern("**{image}**")
+	if err != nil {
+		t.Fatalf("Error in FilterPattern: %v", err)
+	}
+	if len(images.Changes) != 2 {
+		t.Errorf("Expected 2 image changes, got: %v", images.Changes)
+	}
+	for _, change := range images.Changes {
+		if change.GetPath()[0].GetName() != "StrIntMap" && change.GetPath()[0].GetName() != "MapOfMaps" {
+			t.Errorf("Unexpected change: %v", change)
+		}
+	}
+
+	partitions := diff.Partition()
+	total := 0
+	for name, partition := range partitions {
+		if partition.BaseType != diff.BaseType {
+			t.Errorf("Partition %v has the wrong BaseType %v", name, partition.BaseType)
+		}
+		total += len(partition.Changes)
+	}
+	if total != len(diff.Changes) || len(partitions["NestedObj"].Changes) != 2 {
+		t.Errorf("Unexpected partitions: %v", partitions)
+	}
+}
+
+func TestChangeSetRebase(t *testing.T) {
+	o1, o2 := buildQueryObjects()
+	diff, err := Diff(&o1, &o2)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	prefix := []PathElement{NewPtrElem(), NewFieldElem(12, "MapOfMaps"), NewKeyElem("a")}
+	rebased, err := diff.FilterPrefix(prefix).Rebase(prefix)
+	if err != nil {
+		t.Fatalf("Error in Rebase: %v", err)
+	}
+	if rebased.BaseType != reflect.TypeOf(map[string]NestObj{}) {
+		t.Fatalf("Unexpected BaseType: %v", rebased.BaseType)
+	}
+
+	subtree := map[string]NestObj{"image": o1.MapOfMaps["a"]["image"]}
+	if err := rebased.Patch(&subtree); err != nil {
+		t.Fatalf("Error in Patch: %v", err)
+	}
+	if !reflect.DeepEqual(subtree, o2.MapOfMaps["a"]) {
+		t.Logf("Expect: %+v", o2.MapOfMaps["a"])
+		t.Logf("Actual: %+v", subtree)
+		t.Fail()
+	}
+
+	if _, err := diff.Rebase(prefix); err == nil {
+		t.Errorf("Expected an error rebasing changes outside of the prefix")
+	}
+}
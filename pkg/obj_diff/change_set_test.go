@@ -5,8 +5,11 @@
 package obj_diff
 
 import (
+	"errors"
 	"fmt"
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"reflect"
 	"testing"
 )
 
@@ -154,3 +157,316 @@ func TestAddByteArrayToMap(t *testing.T) {
 		t.Fatalf("Error in Patch: %v", err)
 	}
 }
+
+func TestPatchAtomic(t *testing.T) {
+	base := Obj{Int: 1, Str: "Foo", IntList: []int64{1, 2}}
+	failing := ChangeSet{
+		BaseType: reflect.TypeOf(base),
+		Changes: []Change{
+			NewValueChange([]PathElement{NewFieldElem(0, "Int")}, reflect.ValueOf(int32(1)), reflect.ValueOf(int32(5))),
+			NewValueChange([]PathElement{NewFieldElem(3, "Str")}, reflect.ValueOf("Foo"), reflect.ValueOf("Bar")),
+			// The slice only has two elements, this can not be applied.
+			NewValueAddition([]PathElement{NewFieldElem(5, "IntList"), NewIndexElem(7)}, reflect.ValueOf(int64(3))),
+		},
+	}
+
+	partial := base
+	partial.IntList = []int64{1, 2}
+	if err := failing.Patch(&partial); err == nil {
+		t.Fatalf("Expected an error from Patch")
+	}
+	if partial.Int != 5 {
+		t.Fatalf("Expected Patch to have partially applied, got: %+v", partial)
+	}
+
+	atomic := base
+	atomic.IntList = []int64{1, 2}
+	if err := failing.PatchAtomic(&atomic); err == nil {
+		t.Fatalf("Expected an error from PatchAtomic")
+	}
+	if !reflect.DeepEqual(base, atomic) {
+		t.Logf("Expect: %+v", base)
+		t.Logf("Actual: %+v", atomic)
+		t.Fatalf("PatchAtomic modified the object on failure")
+	}
+
+	update := Obj{Int: 2, Str: "Bar", IntList: []int64{1, 2, 3}}
+	diff, err := Diff(&base, &update)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	target := base
+	target.IntList = []int64{1, 2}
+	targetPtr := &target
+	if err := diff.PatchAtomic(targetPtr); err != nil {
+		t.Fatalf("Error in PatchAtomic: %v", err)
+	}
+	if targetPtr != &target || !reflect.DeepEqual(update, target) {
+		t.Logf("Expect: %+v", update)
+		t.Logf("Actual: %+v", target)
+		t.Fail()
+	}
+}
+
+func TestApply(t *testing.T) {
+	four := int16(4)
+	five := int16(5)
+	o1 := Obj{Int: 1, IntPtr: &four, Str: "Foo", IntList: []int64{1, 2},
+		StrIntMap: map[string]int64{"a": 1, "b": 2}}
+	o2 := Obj{Int: 2, IntPtr: &five, Str: "Bar", IntList: []int64{1, 2, 3},
+		StrIntMap: map[string]int64{"a": 2, "c": 3}}
+
+	diff, err := Diff(o1, o2)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	original := CopyValueReflectively(o1)
+	actual, err := diff.Apply(o1)
+	if err != nil {
+		t.Fatalf("Error in Apply: %v", err)
+	}
+	if !reflect.DeepEqual(o2, actual) {
+		t.Logf("Expect: %+v", o2)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+	if !reflect.DeepEqual(original, o1) {
+		t.Logf("Expect: %+v", original)
+		t.Logf("Actual: %+v", o1)
+		t.Fatalf("Apply modified the original object")
+	}
+
+	actualPtr, err := diff.Apply(&o1)
+	if err != nil {
+		t.Fatalf("Error in Apply: %v", err)
+	}
+	if ptr, ok := actualPtr.(*Obj); !ok || ptr == &o1 || !reflect.DeepEqual(o2, *ptr) {
+		t.Logf("Expect: %+v", &o2)
+		t.Logf("Actual: %+v", actualPtr)
+		t.Fail()
+	}
+
+	basic, err := Diff(int(-123), int(123))
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+	if actual, err := basic.Apply(int(-123)); err != nil || actual != int(123) {
+		t.Fatalf("Expected 123, got: %v (%v)", actual, err)
+	}
+
+	if _, err := diff.Apply("Hello"); err == nil {
+		t.Fatalf("Expected an error applying to the wrong type")
+	}
+}
+
+type fieldsV1 struct {
+	Str    string
+	Nested NestObj
+	Map    map[string]NestObj
+}
+
+type fieldsV2 struct {
+	Added  int
+	Map    map[string]NestObj
+	Nested NestObj
+	Str    string
+}
+
+func TestPatchReorderedFields(t *testing.T) {
+	diff, err := Diff(
+		fieldsV1{Str: "a", Nested: NestObj{1, "A"}, Map: map[string]NestObj{"x": {1, "A"}}},
+		fieldsV1{Str: "b", Nested: NestObj{2, "A"}, Map: map[string]NestObj{"x": {1, "B"}}})
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	// Simulate a ChangeSet stored before the fields were reordered.
+	diff.BaseType = reflect.TypeOf(fieldsV2{})
+	actual := fieldsV2{Added: 7, Str: "a", Nested: NestObj{1, "A"}, Map: map[string]NestObj{"x": {1, "A"}}}
+	expect := fieldsV2{Added: 7, Str: "b", Nested: NestObj{2, "A"}, Map: map[string]NestObj{"x": {1, "B"}}}
+	if err := diff.Patch(&actual); err != nil {
+		t.Fatalf("Error in Patch: %v", err)
+	}
+	if !reflect.DeepEqual(expect, actual) {
+		t.Logf("Expect: %+v", expect)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+
+	missing := ChangeSet{BaseType: reflect.TypeOf(NestObj{}), Changes: []Change{
+		NewValueChange([]PathElement{NewFieldElem(1, "Removed")}, reflect.ValueOf("a"), reflect.ValueOf("b")),
+	}}
+	nest := NestObj{1, "a"}
+	if err := missing.Patch(&nest); err == nil {
+		t.Errorf("Expected an error patching a missing field")
+	}
+	if nest.Str != "a" {
+		t.Errorf("Expected Str to be unchanged, got: %v", nest.Str)
+	}
+}
+
+func TestPatchSliceInsertDelete(t *testing.T) {
+	type sliceObj struct {
+		List []int
+		Map  map[string][]int
+		Ptrs []*NestObj
+	}
+
+	list := NewFieldElem(0, "List")
+	mapElem := NewFieldElem(1, "Map")
+	ptrs := NewFieldElem(2, "Ptrs")
+	nest := NestObj{1, "A"}
+	cs := ChangeSet{BaseType: reflect.TypeOf(sliceObj{}), Changes: []Change{
+		NewValueDeletion([]PathElement{list, NewIndexElem(1)}, reflect.ValueOf(2)),
+		NewValueAddition([]PathElement{list, NewIndexElem(0)}, reflect.ValueOf(9)),
+		NewValueAddition([]PathElement{list, NewIndexElem(3)}, reflect.ValueOf(7)),
+		NewValueDeletion([]PathElement{mapElem, NewKeyElem("a"), NewIndexElem(0)}, reflect.ValueOf(1)),
+		NewValueAddition([]PathElement{mapElem, NewKeyElem("a"), NewIndexElem(1)}, reflect.ValueOf(5)),
+		NewValueAddition([]PathElement{mapElem, NewKeyElem("b"), NewIndexElem(0)}, reflect.ValueOf(6)),
+		NewValueDeletion([]PathElement{ptrs, NewIndexElem(0), NewPtrElem()}, reflect.ValueOf(nest)),
+	}}
+
+	original := sliceObj{List: []int{1, 2, 3}, Map: map[string][]int{"a": {1, 2, 3}}, Ptrs: []*NestObj{&nest}}
+	actual := CopyValueReflectively(original).(sliceObj)
+	expect := sliceObj{List: []int{9, 1, 3, 7}, Map: map[string][]int{"a": {2, 5, 3}, "b": {6}}, Ptrs: []*NestObj{nil}}
+	if err := cs.Patch(&actual); err != nil {
+		t.Fatalf("Error in Patch: %v", err)
+	}
+	if !reflect.DeepEqual(expect, actual) {
+		t.Logf("Expect: %+v", expect)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+
+	outOfRange := ChangeSet{BaseType: cs.BaseType, Changes: []Change{
+		NewValueAddition([]PathElement{list, NewIndexElem(5)}, reflect.ValueOf(1)),
+	}}
+	if err := outOfRange.Patch(&actual); err == nil {
+		t.Errorf("Expected an error inserting past the end of a slice")
+	}
+}
+
+type resetObj struct {
+	Any   interface{}
+	Other interface{}
+	List  []interface{}
+	Map   map[string]interface{}
+	Ptrs  [2]*NestObj
+	Nest  NestObj
+	Bools [3]bool
+}
+
+func TestDiffThenPatchResets(t *testing.T) {
+	nest := NestObj{1, "A"}
+	o1 := resetObj{Any: "a", List: []interface{}{1, nil, "x"}, Map: map[string]interface{}{"a": nil, "b": 2},
+		Ptrs: [2]*NestObj{&nest, nil}, Nest: nest, Bools: [3]bool{true, true, false}}
+	o2 := resetObj{Other: nest, List: []interface{}{nil, 2, "x"}, Map: map[string]interface{}{"a": 1, "b": nil},
+		Ptrs: [2]*NestObj{nil, &nest}, Nest: nest, Bools: [3]bool{true, true, false}}
+
+	diff, err := Diff(o1, o2)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	actual := CopyValueReflectively(o1).(resetObj)
+	if err := diff.Patch(&actual); err != nil {
+		t.Fatalf("Error in Patch: %v", err)
+	}
+	if !reflect.DeepEqual(o2, actual) {
+		t.Logf("Changes: %v", diff.Changes)
+		t.Logf("Expect: %+v", o2)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+
+	resets := ChangeSet{BaseType: reflect.TypeOf(resetObj{}), Changes: []Change{
+		NewValueDeletion([]PathElement{NewFieldElem(5, "Nest")}, reflect.ValueOf(nest)),
+		NewValueDeletion([]PathElement{NewFieldElem(6, "Bools"), NewIndexElem(1)}, reflect.ValueOf(true)),
+		NewValueDeletion([]PathElement{NewFieldElem(1, "Other")}, reflect.ValueOf(nest)),
+	}}
+	if err := resets.Patch(&actual); err != nil {
+		t.Fatalf("Error in Patch: %v", err)
+	}
+	expect := CopyValueReflectively(o2).(resetObj)
+	expect.Nest = NestObj{}
+	expect.Bools[1] = false
+	expect.Other = nil
+	if !reflect.DeepEqual(expect, actual) {
+		t.Logf("Expect: %+v", expect)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+}
+
+func TestPatchWithOptions(t *testing.T) {
+	objType := reflect.TypeOf(Obj{})
+	cs := ChangeSet{BaseType: objType, Changes: []Change{
+		NewValueChange([]PathElement{NewFieldElem(3, "Str")}, reflect.ValueOf("a"), reflect.ValueOf("b")),
+		NewValueChange([]PathElement{NewFieldElem(10, "NestedPtr2"), NewPtrElem(), NewFieldElem(0, "Int")}, reflect.ValueOf(int64(1)), reflect.ValueOf(int64(2))),
+		NewValueChange([]PathElement{NewFieldElem(12, "MapOfMaps"), NewKeyElem("x"), NewKeyElem("y")}, reflect.ValueOf(NestObj{}), reflect.ValueOf(NestObj{1, "A"})),
+		NewValueChange([]PathElement{NewFieldElem(0, "Int")}, reflect.ValueOf(int32(1)), reflect.ValueOf(int32(2))),
+	}}
+
+	actual := Obj{Str: "a", Int: 1, MapOfMaps: map[string]map[string]NestObj{}}
+	err := cs.PatchWithOptions(&actual, PatchOptions{ObjectPathConfig: DefaultObjectPathConfig()})
+	if _, ok := err.(PathNotFoundError); !ok {
+		t.Errorf("Expected a PathNotFoundError, got: %v", err)
+	}
+	if actual.Str != "b" || actual.Int != 1 {
+		t.Errorf("Expected only the first change to be applied, got: %+v", actual)
+	}
+
+	actual = Obj{Str: "a", Int: 1, MapOfMaps: map[string]map[string]NestObj{}}
+	err = cs.PatchWithOptions(&actual, PatchOptions{ObjectPathConfig: DefaultObjectPathConfig(), OnMissingParent: SkipOnMissingParent})
+	if err != nil {
+		t.Fatalf("Error in PatchWithOptions: %v", err)
+	}
+	expect := Obj{Str: "b", Int: 2, MapOfMaps: map[string]map[string]NestObj{}}
+	if !reflect.DeepEqual(expect, actual) {
+		t.Logf("Expect: %+v", expect)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+
+	actual = Obj{Str: "a", Int: 1, MapOfMaps: map[string]map[string]NestObj{}}
+	err = cs.PatchWithOptions(&actual, PatchOptions{ObjectPathConfig: DefaultObjectPathConfig(), Atomic: true})
+	if err == nil || actual.Str != "a" {
+		t.Errorf("Expected an atomic patch to fail without changes, got: %v, %+v", err, actual)
+	}
+
+	// Nil maps and slices are missing when they can not be created.
+	nilParents := ChangeSet{BaseType: objType, Changes: []Change{
+		NewValueAddition([]PathElement{NewFieldElem(7, "StrIntMap"), NewKeyElem("a")}, reflect.ValueOf(int64(1))),
+		NewValueAddition([]PathElement{NewFieldElem(5, "IntList"), NewIndexElem(0)}, reflect.ValueOf(int64(1))),
+		NewValueChange([]PathElement{NewFieldElem(0, "Int")}, reflect.ValueOf(int32(1)), reflect.ValueOf(int32(2))),
+	}}
+	for _, config := range []ObjectPathConfig{DefaultObjectPathConfig(), {false, true}} {
+		actual = Obj{Int: 1}
+		err = nilParents.PatchWithOptions(&actual, PatchOptions{ObjectPathConfig: config})
+		if !errors.Is(err, ErrPathNotFound) {
+			t.Errorf("Expected a PathNotFoundError for %+v, got: %v", config, err)
+		}
+
+		actual = Obj{Int: 1}
+		err = nilParents.PatchWithOptions(&actual, PatchOptions{ObjectPathConfig: config, OnMissingParent: SkipOnMissingParent})
+		if err != nil {
+			t.Fatalf("Error in PatchWithOptions: %v", err)
+		}
+		if expect := (Obj{Int: 2}); !reflect.DeepEqual(expect, actual) {
+			t.Logf("Expect: %+v", expect)
+			t.Logf("Actual: %+v", actual)
+			t.Fail()
+		}
+	}
+
+	outOfRange := ChangeSet{BaseType: objType, Changes: []Change{
+		NewValueChange([]PathElement{NewFieldElem(5, "IntList"), NewIndexElem(3)}, reflect.ValueOf(int64(1)), reflect.ValueOf(int64(2))),
+	}}
+	actual = Obj{IntList: []int64{1}}
+	err = outOfRange.Patch(&actual)
+	if rangeErr, ok := err.(IndexOutOfRangeError); !ok || rangeErr.Index != 3 || rangeErr.Len != 1 {
+		t.Errorf("Expected an IndexOutOfRangeError, got: %v", err)
+	}
+}
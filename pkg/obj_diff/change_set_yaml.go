@@ -0,0 +1,31 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"github.com/ghodss/yaml"
+	"reflect"
+)
+
+// Encodes this ChangeSet as YAML. The document has the same structure as
+// the JSON encoding produced by MarshalJSON.
+func (cs ChangeSet) ToYAML() ([]byte, error) {
+	data, err := cs.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.JSONToYAML(data)
+}
+
+// Decodes a ChangeSet produced by ToYAML for the given baseType.
+func UnmarshalChangeSetYAML(data []byte, baseType reflect.Type) (*ChangeSet, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return UnmarshalChangeSet(jsonData, baseType)
+}
@@ -9,54 +9,228 @@ import (
 	"reflect"
 )
 
+// Options for copying an object.
+type CopyOptions struct {
+	// Copy each pointer only once, so a value reached through several
+	// pointers is shared in the copy the same way it is in the original. This
+	// also allows copying cyclic objects. DeepCopy methods are not used in
+	// this mode as they would duplicate shared values.
+	PreserveAliases bool
+	// Copy only the values matching one of these patterns, along with
+	// everything below them. Everything else is left at its zero value. All
+	// values are copied if there are no patterns.
+	Include []*PathPattern
+	// Leave the values matching one of these patterns, and everything below
+	// them, at their zero value.
+	Exclude []*PathPattern
+	// Called with each selected value and its path. If it returns true the
+	// value is replaced in the copy by the returned value, or by the zero
+	// value if it is not assignable to the type of the original.
+	//
+	// Unexported struct fields can not be filtered or redacted. They are
+	// kept with their struct when it is selected, unless an Exclude pattern
+	// matches one of them, otherwise they are left at their zero value.
+	Redact func(path []PathElement, value reflect.Value) (reflect.Value, bool)
+}
+
+// Returns true if the copy is filtered or redacted.
+func (opts CopyOptions) filtered() bool {
+	return len(opts.Include) > 0 || len(opts.Exclude) > 0 || opts.Redact != nil
+}
+
 // Reflectively make a copy of an object. This uses reflection to
 // traverse an object and create a copy.
 func CopyValueReflectively(oldValue interface{}) interface{} {
-	return CopyReflectValue(reflect.ValueOf(oldValue)).Interface()
+	return CopyValueReflectivelyWithOptions(oldValue, CopyOptions{})
+}
+
+// Reflectively make a copy of an object as controlled by opts.
+func CopyValueReflectivelyWithOptions(oldValue interface{}, opts CopyOptions) interface{} {
+	if oldValue == nil {
+		return nil
+	}
+	return CopyReflectValueWithOptions(reflect.ValueOf(oldValue), opts).Interface()
+}
+
+// Reflectively and recursively makes a copy of a reflect.Value. Types with
+// DeepCopy, DeepCopyInto or DeepCopyObject methods, such as the Kubernetes
+// API types, are copied with those methods. Funcs, chans and unsafe pointers
+// can not be copied so they are shared with the original.
+func CopyReflectValue(oldVal reflect.Value) reflect.Value {
+	return CopyReflectValueWithOptions(oldVal, CopyOptions{})
+}
+
+// Reflectively and recursively makes a copy of a reflect.Value as
+// controlled by opts. The copy has the same type as oldVal. When aliases are
+// preserved a value reached through several paths is filtered by the first
+// path it is copied through.
+func CopyReflectValueWithOptions(oldVal reflect.Value, opts CopyOptions) reflect.Value {
+	c := copier{opts: opts}
+	if opts.PreserveAliases {
+		c.copies = map[aliasKey]reflect.Value{}
+	}
+	return c.copy(oldVal, []PathElement{}, len(opts.Include) == 0)
+}
+
+// Identifies a pointer, the type is included as a struct and its first
+// field share an address.
+type aliasKey struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+type copier struct {
+	opts CopyOptions
+	// The copy made of each pointer when preserving aliases.
+	copies map[aliasKey]reflect.Value
+}
+
+// Returns the path to a child of path, paths are only tracked when the copy
+// is filtered.
+func (c copier) child(path []PathElement, pe PathElement) []PathElement {
+	if !c.opts.filtered() {
+		return path
+	}
+	return extendContext(path, pe)
+}
+
+// Returns true if the value at path is copied, and true if it is included
+// as a whole rather than only to hold included values below it.
+func (c copier) selects(path []PathElement, included bool) (bool, bool) {
+	if matchAnyPattern(c.opts.Exclude, path) {
+		return false, false
+	}
+	if included || matchAnyPattern(c.opts.Include, path) {
+		return true, true
+	}
+	// Nothing below here can be included.
+	return matchAnyPatternPrefix(c.opts.Include, path), false
 }
 
-// Reflectively and recursively makes a copy of a reflect.Value.
-func CopyReflectValue(oldVal reflect.Value) (newVal reflect.Value) {
+// Copies oldVal found at path, included is true if path or one of its
+// parents matched an Include pattern.
+func (c copier) copy(oldVal reflect.Value, path []PathElement, included bool) (newVal reflect.Value) {
+	if !oldVal.IsValid() {
+		return oldVal
+	}
+
+	if c.opts.filtered() {
+		var selected bool
+		if selected, included = c.selects(path, included); !selected {
+			return reflect.Zero(oldVal.Type())
+		}
+		if included && c.opts.Redact != nil {
+			if redacted, ok := c.opts.Redact(path, oldVal); ok {
+				newVal = reflect.New(oldVal.Type()).Elem()
+				// Anything which can not be assigned is left at zero.
+				if redacted.IsValid() && redacted.Type().AssignableTo(oldVal.Type()) {
+					newVal.Set(redacted)
+				}
+				return newVal
+			}
+		}
+	}
+
+	// DeepCopy methods can not apply the filters below this value.
+	if !c.opts.PreserveAliases && len(c.opts.Exclude) == 0 && c.opts.Redact == nil && included {
+		if copied, ok := deepCopyWithMethod(oldVal); ok {
+			return copied
+		}
+	}
+
 	newType := oldVal.Type()
 	switch newType.Kind() {
 	case reflect.Struct:
 		newVal = reflect.New(newType).Elem()
 		// 	newVal = reflect.Zero(newType)
+		if c.opts.filtered() && hasUnexportedFields(newType) {
+			if c.keepsUnexported(newType, path, included) {
+				if !c.opts.PreserveAliases && c.opts.Redact == nil && !matchAnyPatternPrefix(c.opts.Exclude, path) {
+					// Nothing inside is filtered, so it is copied whole.
+					if copied, ok := deepCopyWithMethod(oldVal); ok {
+						return copied
+					}
+				}
+				// The unexported fields are shared, the exported fields
+				// are filtered below.
+				newVal.Set(oldVal)
+			}
+		}
+
 		for f := 0; f < newType.NumField(); f++ {
 			oldField := oldVal.Field(f)
-			if !oldField.CanInterface() {
+			if !oldField.CanInterface() && c.opts.filtered() {
+				// Unexported fields can not be filtered or redacted, they
+				// are either kept above or left at zero.
+				continue
+			} else if !oldField.CanInterface() {
 				// We set and break because all elements of this obj are not interface-able.
 				newVal.Set(oldVal)
 				break
 			}
-			newVal.Field(f).Set(CopyReflectValue(oldField))
+			field := NewFieldElem(f, newType.Field(f).Name)
+			newVal.Field(f).Set(c.copy(oldField, c.child(path, field), included))
 		}
 
 	case reflect.Map:
+		if oldVal.IsNil() {
+			newVal = reflect.Zero(newType)
+			break
+		}
 		newVal = reflect.MakeMapWithSize(newType, oldVal.Len())
 		for _, key := range oldVal.MapKeys() {
-			newVal.SetMapIndex(CopyReflectValue(key), CopyReflectValue(oldVal.MapIndex(key)))
+			keyPath := c.child(path, NewKeyElem(key))
+			if c.opts.filtered() {
+				if selected, _ := c.selects(keyPath, included); !selected {
+					// Unselected entries are left out rather than zeroed.
+					continue
+				}
+			}
+			newVal.SetMapIndex(CopyReflectValue(key), c.copy(oldVal.MapIndex(key), keyPath, included))
 		}
 
 	case reflect.Array:
 		newVal = reflect.New(newType).Elem()
 		for i := 0; i < oldVal.Len(); i++ {
-			newVal.Index(i).Set(CopyReflectValue(oldVal.Index(i)))
+			newVal.Index(i).Set(c.copy(oldVal.Index(i), c.child(path, NewIndexElem(i)), included))
 		}
 
 	case reflect.Slice:
+		if oldVal.IsNil() {
+			newVal = reflect.Zero(newType)
+			break
+		}
 		newVal = reflect.MakeSlice(newType, oldVal.Len(), oldVal.Cap())
 		for i := 0; i < oldVal.Len(); i++ {
-			newVal.Index(i).Set(CopyReflectValue(oldVal.Index(i)))
+			newVal.Index(i).Set(c.copy(oldVal.Index(i), c.child(path, NewIndexElem(i)), included))
 		}
 
 	case reflect.Ptr:
 		if oldVal.IsNil() {
 			newVal = reflect.Zero(newType)
-		} else {
-			newVal = reflect.New(newType.Elem())
-			newVal.Elem().Set(CopyReflectValue(oldVal.Elem()))
+			break
+		}
+
+		key := aliasKey{oldVal.Pointer(), newType}
+		if copied, ok := c.copies[key]; ok {
+			return copied
+		}
+		newVal = reflect.New(newType.Elem())
+		if c.copies != nil {
+			// Recorded before copying what it points at so cycles end here.
+			c.copies[key] = newVal
 		}
+		newVal.Elem().Set(c.copy(oldVal.Elem(), c.child(path, NewPtrElem()), included))
+
+	case reflect.Interface:
+		newVal = reflect.New(newType).Elem()
+		if !oldVal.IsNil() {
+			newVal.Set(c.copy(oldVal.Elem(), path, included))
+		}
+
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		newVal = reflect.New(newType).Elem()
+		newVal.Set(oldVal)
 
 	default:
 		newVal = copyBasic(oldVal).Convert(oldVal.Type())
@@ -65,6 +239,54 @@ func CopyReflectValue(oldVal reflect.Value) (newVal reflect.Value) {
 	return
 }
 
+// Copies oldVal with its DeepCopy, DeepCopyInto or DeepCopyObject method,
+// in that order. Returns false if it has none of them.
+func deepCopyWithMethod(oldVal reflect.Value) (reflect.Value, bool) {
+	typ := oldVal.Type()
+	if !oldVal.CanInterface() || typ.Kind() == reflect.Interface {
+		return reflect.Value{}, false
+	}
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if oldVal.IsNil() {
+			// Left to the copier so nil stays nil.
+			return reflect.Value{}, false
+		}
+	}
+
+	// func (in T) DeepCopy() T, or with a pointer T.
+	if method, ok := typ.MethodByName("DeepCopy"); ok &&
+		method.Type.NumIn() == 1 && method.Type.NumOut() == 1 && method.Type.Out(0) == typ {
+		return oldVal.Method(method.Index).Call(nil)[0], true
+	}
+
+	// func (in *T) DeepCopyInto(out *T), the receiver is a pointer so we
+	// call it on an addressable copy of oldVal.
+	ptrType := reflect.PtrTo(typ)
+	if method, ok := ptrType.MethodByName("DeepCopyInto"); ok &&
+		method.Type.NumIn() == 2 && method.Type.In(1) == ptrType && method.Type.NumOut() == 0 {
+		in := reflect.New(typ)
+		in.Elem().Set(oldVal)
+		out := reflect.New(typ)
+		in.Method(method.Index).Call([]reflect.Value{out})
+		return out.Elem(), true
+	}
+
+	// func (in *T) DeepCopyObject() runtime.Object
+	if method, ok := typ.MethodByName("DeepCopyObject"); ok &&
+		method.Type.NumIn() == 1 && method.Type.NumOut() == 1 {
+		copied := oldVal.Method(method.Index).Call(nil)[0]
+		if copied.Kind() == reflect.Interface {
+			copied = copied.Elem()
+		}
+		if copied.IsValid() && copied.Type() == typ {
+			return copied, true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
 // Make a copy of a basic non-container type.
 func copyBasic(oldVal reflect.Value) (newVal reflect.Value) {
 	switch oldVal.Kind() {
@@ -92,6 +314,8 @@ func copyBasic(oldVal reflect.Value) (newVal reflect.Value) {
 		newVal = reflect.ValueOf(uint8(oldVal.Uint()))
 	case reflect.Uint:
 		newVal = reflect.ValueOf(uint(oldVal.Uint()))
+	case reflect.Uintptr:
+		newVal = reflect.ValueOf(uintptr(oldVal.Uint()))
 
 	case reflect.Float64:
 		newVal = reflect.ValueOf(oldVal.Float())
@@ -107,8 +331,56 @@ func copyBasic(oldVal reflect.Value) (newVal reflect.Value) {
 		newVal = reflect.ValueOf(oldVal.Bool())
 
 	default:
-		panic(NewPatchError("unhandled basic kind '%v'\n", oldVal.Kind()))
+		// Nothing else can be copied, so the value is shared.
+		newVal = oldVal
 	}
 
 	return
 }
+
+// Returns true if the unexported fields of a struct of structType at path
+// are kept in a filtered copy. These can not be set individually, so they
+// are kept only if the struct is included and no Exclude pattern matches
+// any of them. Patterns can not select anything below an unexported field.
+func (c copier) keepsUnexported(structType reflect.Type, path []PathElement, included bool) bool {
+	if !included {
+		return false
+	}
+	for f := 0; f < structType.NumField(); f++ {
+		field := structType.Field(f)
+		if len(field.PkgPath) > 0 && matchAnyPattern(c.opts.Exclude, extendContext(path, NewFieldElem(f, field.Name))) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns true if structType has any unexported fields.
+func hasUnexportedFields(structType reflect.Type) bool {
+	for f := 0; f < structType.NumField(); f++ {
+		if len(structType.Field(f).PkgPath) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if any of patterns matches path.
+func matchAnyPattern(patterns []*PathPattern, path []PathElement) bool {
+	for _, pattern := range patterns {
+		if pattern.Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if any of patterns could match path or a path below it.
+func matchAnyPatternPrefix(patterns []*PathPattern, path []PathElement) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchPrefix(path) {
+			return true
+		}
+	}
+	return false
+}
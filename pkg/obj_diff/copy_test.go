@@ -5,9 +5,11 @@
 package obj_diff
 
 import (
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"reflect"
 	"testing"
+	"time"
 )
 
 type simpleStruct struct {
@@ -101,6 +103,7 @@ func TestCopyValueReflectively(t *testing.T) {
 	}{
 		{name: "Basic -- Int", object: int(-123)},
 		{name: "Basic -- Uint", object: uint(123)},
+		{name: "Basic -- Uintptr", object: uintptr(5)},
 		{name: "Basic -- Float", object: float64(3.141586)},
 		{name: "Basic -- Complex", object: complex128(123.45 + 456.78i)},
 		{name: "Basic -- Bool", object: true},
@@ -193,3 +196,201 @@ func TestTypeAlias(t *testing.T) {
 	}
 
 }
+
+type deepCopied struct {
+	A []int
+}
+
+// Marks its copies so the test can tell the method was used.
+func (in *deepCopied) DeepCopyInto(out *deepCopied) {
+	out.A = append([]int{-1}, in.A...)
+}
+
+type deepCopiedObject struct {
+	A int
+}
+
+func (in *deepCopiedObject) DeepCopyObject() interface{} {
+	return &deepCopiedObject{A: in.A + 1}
+}
+
+func TestCopyDeepCopyMethods(t *testing.T) {
+	expect := map[string]deepCopied{"a": {A: []int{-1, 1}}}
+	actual := CopyValueReflectively(map[string]deepCopied{"a": {A: []int{1}}})
+	if !reflect.DeepEqual(expect, actual) {
+		t.Logf("Expect: %+v", expect)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+
+	object := CopyValueReflectively(&deepCopiedObject{A: 1}).(*deepCopiedObject)
+	if object.A != 2 {
+		t.Logf("Expect: %+v", 2)
+		t.Logf("Actual: %+v", object.A)
+		t.Fail()
+	}
+
+	// Alias preserving copies are made reflectively.
+	aliased := CopyValueReflectivelyWithOptions(deepCopied{A: []int{1}}, CopyOptions{PreserveAliases: true})
+	if !reflect.DeepEqual(deepCopied{A: []int{1}}, aliased) {
+		t.Logf("Expect: %+v", deepCopied{A: []int{1}})
+		t.Logf("Actual: %+v", aliased)
+		t.Fail()
+	}
+}
+
+type aliasNode struct {
+	Name     string
+	Children []*aliasNode
+}
+
+func TestCopyPreserveAliases(t *testing.T) {
+	shared := &aliasNode{Name: "shared"}
+	root := &aliasNode{Name: "root", Children: []*aliasNode{shared, {Name: "b", Children: []*aliasNode{shared}}}}
+
+	copied := CopyValueReflectivelyWithOptions(root, CopyOptions{PreserveAliases: true}).(*aliasNode)
+	if !reflect.DeepEqual(root, copied) {
+		t.Logf("Expect: %+v", root)
+		t.Logf("Actual: %+v", copied)
+		t.Fail()
+	}
+	if copied.Children[0] == shared || copied.Children[0] != copied.Children[1].Children[0] {
+		t.Errorf("Expected the shared node to be copied once")
+	}
+
+	plain := CopyValueReflectively(root).(*aliasNode)
+	if plain.Children[0] == plain.Children[1].Children[0] {
+		t.Errorf("Expected the shared node to be copied twice without PreserveAliases")
+	}
+
+	// Cycles are followed only once.
+	root.Children[1].Children = append(root.Children[1].Children, root)
+	cyclic := CopyValueReflectivelyWithOptions(root, CopyOptions{PreserveAliases: true}).(*aliasNode)
+	if cyclic.Children[1].Children[1] != cyclic {
+		t.Errorf("Expected the cycle to point at the copied root")
+	}
+}
+
+type projectionEnv struct {
+	Name  string
+	Value string
+}
+
+type projectionSpec struct {
+	Image   string
+	Secrets map[string]string
+	Env     []projectionEnv
+	token   string
+}
+
+type projectionObject struct {
+	Name string
+	Spec *projectionSpec
+}
+
+func TestCopyProjection(t *testing.T) {
+	object := projectionObject{Name: "a", Spec: &projectionSpec{
+		Image:   "a:1",
+		Secrets: map[string]string{"password": "hunter2", "token": "abc"},
+		Env:     []projectionEnv{{"USER", "a"}, {"PASSWORD", "hunter2"}},
+		token:   "secret",
+	}}
+	original := CopyValueReflectively(object)
+
+	patterns := func(patterns ...string) []*PathPattern {
+		var parsed []*PathPattern
+		for _, pattern := range patterns {
+			pp, err := NewPathPattern(pattern)
+			if err != nil {
+				t.Fatalf("Error in NewPathPattern: %v", err)
+			}
+			parsed = append(parsed, pp)
+		}
+		return parsed
+	}
+	redactPassword := func(path []PathElement, value reflect.Value) (reflect.Value, bool) {
+		if env, ok := value.Interface().(projectionEnv); ok && env.Name == "PASSWORD" {
+			return reflect.ValueOf(projectionEnv{env.Name, "<redacted>"}), true
+		}
+		return reflect.Value{}, false
+	}
+	redactImage := func(path []PathElement, value reflect.Value) (reflect.Value, bool) {
+		if value.Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		if path[len(path)-1].GetName() == "Image" {
+			return reflect.ValueOf(1), true
+		}
+		return reflect.Value{}, true
+	}
+
+	tests := []struct {
+		name   string
+		opts   CopyOptions
+		expect projectionObject
+	}{
+		{
+			name:   "Include",
+			opts:   CopyOptions{Include: patterns(".Spec.Image", ".Spec.Secrets{token}")},
+			expect: projectionObject{Spec: &projectionSpec{Image: "a:1", Secrets: map[string]string{"token": "abc"}}},
+		},
+		{
+			name: "Exclude",
+			opts: CopyOptions{Include: patterns(".Spec"), Exclude: patterns(".Spec.Secrets{pass*}", ".Spec.Env[0]")},
+			expect: projectionObject{Spec: &projectionSpec{Image: "a:1", Secrets: map[string]string{"token": "abc"},
+				Env: []projectionEnv{{}, {"PASSWORD", "hunter2"}}, token: "secret"}},
+		},
+		{
+			name: "Redact",
+			opts: CopyOptions{Exclude: patterns(".Spec.Secrets"), Redact: redactPassword},
+			expect: projectionObject{Name: "a", Spec: &projectionSpec{Image: "a:1",
+				Env: []projectionEnv{{"USER", "a"}, {"PASSWORD", "<redacted>"}}, token: "secret"}},
+		},
+		{
+			name: "Exclude Unexported",
+			opts: CopyOptions{Exclude: patterns(".Spec.token")},
+			expect: projectionObject{Name: "a", Spec: &projectionSpec{Image: "a:1",
+				Secrets: map[string]string{"password": "hunter2", "token": "abc"},
+				Env:     []projectionEnv{{"USER", "a"}, {"PASSWORD", "hunter2"}}}},
+		},
+		{
+			name:   "Redact Unassignable",
+			opts:   CopyOptions{Include: patterns(".Spec.Image", ".Spec.Env"), Redact: redactImage},
+			expect: projectionObject{Spec: &projectionSpec{Env: []projectionEnv{{}, {}}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := CopyValueReflectivelyWithOptions(object, test.opts)
+			if !reflect.DeepEqual(test.expect, actual) {
+				t.Logf("Expect: %+v", test.expect)
+				t.Logf("Actual: %+v", actual)
+				t.Fail()
+			}
+			if !reflect.DeepEqual(original, object) {
+				t.Fatalf("Copy modified the object")
+			}
+		})
+	}
+}
+
+type auditRecord struct {
+	Secret  string
+	Created time.Time
+}
+
+func TestCopyProjectionUnexported(t *testing.T) {
+	exclude, err := NewPathPattern(".Secret")
+	if err != nil {
+		t.Fatalf("Error in NewPathPattern: %v", err)
+	}
+
+	record := auditRecord{Secret: "hunter2", Created: time.Now()}
+	expect := auditRecord{Created: record.Created}
+	actual := CopyValueReflectivelyWithOptions(record, CopyOptions{Exclude: []*PathPattern{exclude}})
+	if !reflect.DeepEqual(expect, actual) {
+		t.Logf("Expect: %+v", expect)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+}
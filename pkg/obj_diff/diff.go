@@ -6,25 +6,86 @@ package obj_diff
 
 import (
 	"fmt"
+	"math"
 	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 // BUG(11xor6) Renaming of Map keys results in a deletion and addition.
 // BUG(11xor6) Lists with different orders but the same elements will generate changes.
 
+// Options for computing the change set between two objects.
+type DiffOptions struct {
+	// Capture the values of the objects in each Change directly rather than
+	// copies. This is faster, but maps, slices and pointers in the ChangeSet
+	// then alias the objects, so modifying the objects modifies the ChangeSet.
+	ShareValues bool
+}
+
 // Computes the change set between two objects, both objects must have the same type.
-// This returns a ChangeSet on success and an error on failure.
+// This returns a ChangeSet on success and an error on failure. The values in
+// the ChangeSet are copies and never alias either object.
 func Diff(obj1 interface{}, obj2 interface{}) (*ChangeSet, error) {
+	return DiffWithOptions(obj1, obj2, DiffOptions{})
+}
+
+// Computes the change set between two objects as controlled by opts.
+func DiffWithOptions(obj1 interface{}, obj2 interface{}, opts DiffOptions) (*ChangeSet, error) {
 	v1 := reflect.ValueOf(obj1)
 	v2 := reflect.ValueOf(obj2)
 
-	if v1.Type() != v2.Type() {
-		return nil, fmt.Errorf("type of obj1(%T) not equal to obj2(%T)", obj1, obj2)
+	if !v1.IsValid() && !v2.IsValid() {
+		// Two nils are the same, but there is no type to diff against.
+		return &ChangeSet{}, nil
+	} else if !v1.IsValid() || !v2.IsValid() || v1.Type() != v2.Type() {
+		return nil, TypeMismatchError{Path: []PathElement{}, ChangeIndex: -1, Expected: reflect.TypeOf(obj1), Actual: reflect.TypeOf(obj2)}
 	}
 
 	changeSet := &ChangeSet{BaseType: v1.Type()}
-	return changeSet, doDiff(v1.Type(), v1, v2, changeSet, []PathElement{})
+	return changeSet, DiffValuesAt(changeSet, []PathElement{}, v1, v2, opts)
+}
+
+// Computes the changes between two values found at path within an object of
+// the ChangeSet's BaseType and adds them to cs. This is used by generated
+// code to diff the values it does not handle itself.
+func DiffValuesAt(cs *ChangeSet, path []PathElement, v1 reflect.Value, v2 reflect.Value, opts DiffOptions) error {
+	if !v1.IsValid() || !v2.IsValid() || v1.Type() != v2.Type() {
+		return TypeMismatchError{Path: path, ChangeIndex: -1, Expected: valueType(v1), Actual: valueType(v2)}
+	}
+
+	start := len(cs.Changes)
+	if err := doDiff(v1.Type(), v1, v2, cs, path); err != nil {
+		return err
+	}
+
+	if !opts.ShareValues {
+		for i := start; i < len(cs.Changes); i++ {
+			cs.Changes[i] = copyChangeValues(cs.Changes[i])
+		}
+	}
+	return nil
+}
+
+// Returns the type of v, nil if v is invalid.
+func valueType(v reflect.Value) reflect.Type {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Type()
+}
+
+// Returns a copy of change whose old and new values are deep copies.
+func copyChangeValues(change Change) Change {
+	path := change.GetPath()
+	if change.IsDeletion() {
+		return NewValueDeletion(path, CopyReflectValue(change.GetOldValue()))
+	} else if change.IsAddition() {
+		return NewValueAddition(path, CopyReflectValue(change.GetNewValue()))
+	}
+
+	return NewValueChange(path, CopyReflectValue(change.GetOldValue()), CopyReflectValue(change.GetNewValue()))
 }
 
 
@@ -51,32 +112,25 @@ func doDiff(currType reflect.Type, v1 reflect.Value, v2 reflect.Value, cs *Chang
 			}
 		}
 	case reflect.Map:
-		for _, key := range v1.MapKeys() {
+		// Keys are visited in sorted order so that the same inputs always
+		// produce the same ChangeSet.
+		for _, key := range sortedMapKeys(v1, v2) {
+			val1 := v1.MapIndex(key)
 			val2 := v2.MapIndex(key)
 			newCtx := extendContext(ctx, NewKeyElem(key))
-			if val2.IsValid() {
+			if !val2.IsValid() {
+				// Exists in v1 and not in v2.
+				cs.AddPathDeletion(newCtx, val1)
+			} else if !val1.IsValid() {
+				// Exists in v2 and not in v1.
+				cs.AddPathAddition(newCtx, val2)
+			} else {
 				// Exists in both v1 and v2, do they match?
-				err := doDiff(currType.Elem(), v1.MapIndex(key), v2.MapIndex(key), cs, newCtx)
+				err := doDiff(currType.Elem(), val1, val2, cs, newCtx)
 				if err != nil {
-					if IsInterfaceError(err) {
-						// Only structs should create interface errors
-						panic(err)
-					} else {
-						return err
-					}
+					// Interface errors are handled by the enclosing struct.
+					return err
 				}
-			} else {
-				// Exists in v1 and not in v2.
-				cs.AddPathDeletion(newCtx, v1.MapIndex(key))
-			}
-		}
-
-		for _, key := range v2.MapKeys() {
-			val1 := v1.MapIndex(key)
-			if !val1.IsValid() {
-				// Exists in v2 and not in v1.
-				newCtx := extendContext(ctx, NewKeyElem(key))
-				cs.AddPathAddition(newCtx, v2.MapIndex(key))
 			}
 		}
 	case reflect.Array:
@@ -84,12 +138,8 @@ func doDiff(currType reflect.Type, v1 reflect.Value, v2 reflect.Value, cs *Chang
 			newCtx := extendContext(ctx, NewIndexElem(i))
 			err := doDiff(currType.Elem(), v1.Index(i), v2.Index(i), cs, newCtx)
 			if err != nil {
-				if IsInterfaceError(err) {
-					// Only structs should create interface errors
-					panic(err)
-				} else {
-					return err
-				}
+				// Interface errors are handled by the enclosing struct.
+				return err
 			}
 		}
 	case reflect.Slice:
@@ -99,18 +149,16 @@ func doDiff(currType reflect.Type, v1 reflect.Value, v2 reflect.Value, cs *Chang
 			newCtx := extendContext(ctx, NewIndexElem(i))
 			err := doDiff(currType.Elem(), v1.Index(i), v2.Index(i), cs, newCtx)
 			if err != nil {
-				if IsInterfaceError(err) {
-					// Only structs should create interface errors
-					panic(err)
-				} else {
-					return err
-				}
+				// Interface errors are handled by the enclosing struct.
+				return err
 			}
 		}
 
 		if minLen != maxLen {
 			if maxLen == v1.Len() {
-				for i := minLen; i < maxLen; i++ {
+				// Deletions are made from the tail so that each index is
+				// still valid when it is reached.
+				for i := maxLen - 1; i >= minLen; i-- {
 					newCtx := extendContext(ctx, NewIndexElem(i))
 					cs.AddPathDeletion(newCtx, v1.Index(i))
 				}
@@ -133,14 +181,23 @@ func doDiff(currType reflect.Type, v1 reflect.Value, v2 reflect.Value, cs *Chang
 		} else {
 			err := doDiff(currType.Elem(), v1.Elem(), v2.Elem(), cs, newCtx)
 			if err != nil {
-				if IsInterfaceError(err) {
-					// Only structs should create interface errors
-					panic(err)
-				} else {
-					return err
-				}
+				// Interface errors are handled by the enclosing struct.
+				return err
 			}
 		}
+	case reflect.Interface:
+		// The dynamic values are compared as a whole. Only struct fields can be
+		// deleted back to nil, elsewhere becoming nil is a change.
+		isField := len(ctx) > 0 && len(ctx[len(ctx)-1].GetName()) > 0
+		if v1.IsNil() && v2.IsNil() {
+			return nil
+		} else if v1.IsNil() && isField {
+			cs.AddPathAddition(ctx, v2.Elem())
+		} else if v2.IsNil() && isField {
+			cs.AddPathDeletion(ctx, v1.Elem())
+		} else if v1.IsNil() || v2.IsNil() || !reflect.DeepEqual(v1.Interface(), v2.Interface()) {
+			cs.AddPathChange(ctx, v1.Elem(), v2.Elem())
+		}
 	default:
 		return compareBasicType(currType, v1, v2, cs, ctx)
 	}
@@ -157,6 +214,74 @@ func extendContext(ctx []PathElement, pe PathElement) []PathElement {
 	return append(newCtx, pe)
 }
 
+// Returns the union of the keys of v1 and v2 in a stable, sorted order.
+func sortedMapKeys(v1 reflect.Value, v2 reflect.Value) []reflect.Value {
+	keys := v1.MapKeys()
+	for _, key := range v2.MapKeys() {
+		if !v1.MapIndex(key).IsValid() {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return compareMapKeys(keys[i], keys[j]) < 0
+	})
+	return keys
+}
+
+// Orders two map keys, returning a negative number if k1 sorts before k2, a
+// positive number if it sorts after and zero if they are the same key. Keys
+// of orderable kinds are compared by value, NaN sorting before any other
+// float. Anything else falls back to comparing the formatted value, which is
+// stable across runs for structs and arrays of orderable values. Pointer and
+// chan keys format as addresses and NaN keys are never equal to each other,
+// so these are ordered arbitrarily and the order of their changes may vary
+// from run to run.
+func compareMapKeys(k1 reflect.Value, k2 reflect.Value) int {
+	for k1.Kind() == reflect.Interface && !k1.IsNil() {
+		k1 = k1.Elem()
+	}
+	for k2.Kind() == reflect.Interface && !k2.IsNil() {
+		k2 = k2.Elem()
+	}
+
+	if k1.Type() != k2.Type() {
+		return strings.Compare(k1.Type().String(), k2.Type().String())
+	}
+
+	switch k1.Kind() {
+	case reflect.String:
+		return strings.Compare(k1.String(), k2.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(k1.Int() < k2.Int(), k1.Int() > k2.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return compareOrdered(k1.Uint() < k2.Uint(), k1.Uint() > k2.Uint())
+	case reflect.Float32, reflect.Float64:
+		f1, f2 := k1.Float(), k2.Float()
+		if math.IsNaN(f1) || math.IsNaN(f2) {
+			return compareOrdered(!math.IsNaN(f2), !math.IsNaN(f1))
+		}
+		return compareOrdered(f1 < f2, f1 > f2)
+	case reflect.Bool:
+		return compareOrdered(!k1.Bool() && k2.Bool(), k1.Bool() && !k2.Bool())
+	}
+
+	if k1.CanInterface() && k2.CanInterface() && k1.Interface() == k2.Interface() {
+		return 0
+	}
+	return strings.Compare(fmt.Sprintf("%#v", k1), fmt.Sprintf("%#v", k2))
+}
+
+func compareOrdered(less bool, greater bool) int {
+	if less {
+		return -1
+	} else if greater {
+		return 1
+	}
+
+	return 0
+}
+
 func intMin(x int, y int) int {
 	if x < y {
 		return x
@@ -200,6 +325,8 @@ func compareBasicType(currType reflect.Type, v1 reflect.Value, v2 reflect.Value,
 	case reflect.Uint8:
 		fallthrough
 	case reflect.Uint:
+		fallthrough
+	case reflect.Uintptr:
 		if v1.Uint() != v2.Uint() {
 			cs.AddPathChange(ctx, v1, v2)
 		}
@@ -224,7 +351,7 @@ func compareBasicType(currType reflect.Type, v1 reflect.Value, v2 reflect.Value,
 		}
 
 	default:
-		return fmt.Errorf("unhandled kind '%v'\n", currType.Kind())
+		return UnsupportedKindError{Path: ctx, ChangeIndex: -1, Kind: currType.Kind(), Op: "diff"}
 	}
 
 	return nil
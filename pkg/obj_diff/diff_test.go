@@ -8,6 +8,7 @@ import (
 	"fmt"
 	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"math"
 	"reflect"
 	"testing"
 )
@@ -27,6 +28,7 @@ func TestDiff(t *testing.T) {
 	i := []diffTestObject{
 		buildSimpleTest("Basic -- Int", int(-123), int(123), []PathElement{}, int(-123), int(123)),
 		buildSimpleTest("Basic -- Uint", uint(123), uint(456), []PathElement{}, uint(123), uint(456)),
+		buildSimpleTest("Basic -- Uintptr", uintptr(123), uintptr(456), []PathElement{}, uintptr(123), uintptr(456)),
 		buildSimpleTest("Basic -- Float", float64(3.14159), float64(2.71), []PathElement{}, float64(3.14159), float64(2.71)),
 		buildSimpleTest("Basic -- Complex", complex128(-123.45+3.14i), complex128(456.78+2.71i), []PathElement{}, complex128(-123.45+3.14i), complex128(456.78+2.71i)),
 		buildSimpleTest("Basic -- Bool", false, true, []PathElement{}, false, true),
@@ -77,3 +79,160 @@ func buildSimpleTest(name string, base interface{}, update interface{}, path []P
 		},
 	}
 }
+
+func TestDiffMapOrder(t *testing.T) {
+	m1 := map[string]int32{}
+	m2 := map[string]int32{}
+	i1 := map[int]string{}
+	i2 := map[int]string{}
+	for i := 0; i < 50; i++ {
+		m1[fmt.Sprintf("key-%02d", i)] = int32(i)
+		m2[fmt.Sprintf("key-%02d", i+25)] = int32(i)
+		i1[i] = fmt.Sprint(i)
+		i2[i+25] = fmt.Sprint(i + 1)
+	}
+
+	tests := []struct {
+		name   string
+		base   interface{}
+		update interface{}
+	}{
+		{name: "String Keys", base: m1, update: m2},
+		{name: "Int Keys", base: i1, update: i2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expect, err := Diff(test.base, test.update)
+			if err != nil {
+				t.Fatalf("error in test: %v", err)
+			}
+
+			for i := 0; i < len(expect.Changes)-1; i++ {
+				k1 := expect.Changes[i].GetPath()[0].GetKey()
+				k2 := expect.Changes[i+1].GetPath()[0].GetKey()
+				if compareMapKeys(k1, k2) >= 0 {
+					t.Fatalf("Changes out of order: %v, %v", expect.Changes[i], expect.Changes[i+1])
+				}
+			}
+
+			for i := 0; i < 10; i++ {
+				actual, err := Diff(test.base, test.update)
+				if err != nil {
+					t.Fatalf("error in test: %v", err)
+				}
+
+				if !expect.Equals(*actual) {
+					t.Logf("Expect: %+v", expect)
+					t.Logf("Actual: %+v", actual)
+					t.Fatalf("Diff is not deterministic")
+				}
+			}
+		})
+	}
+}
+
+func TestCompareMapKeysNaN(t *testing.T) {
+	nan := reflect.ValueOf(math.NaN())
+	one := reflect.ValueOf(1.0)
+	if compareMapKeys(nan, one) >= 0 || compareMapKeys(one, nan) <= 0 {
+		t.Errorf("Expected NaN to sort before other floats")
+	}
+}
+
+func TestChangeSetEqualsUnordered(t *testing.T) {
+	c1 := NewValueChange([]PathElement{NewKeyElem("a")}, reflect.ValueOf(int32(1)), reflect.ValueOf(int32(2)))
+	c2 := NewValueAddition([]PathElement{NewKeyElem("b")}, reflect.ValueOf(int32(3)))
+	c3 := NewValueDeletion([]PathElement{NewKeyElem("c")}, reflect.ValueOf(int32(4)))
+	baseType := reflect.TypeOf(map[string]int32{})
+
+	cs1 := ChangeSet{BaseType: baseType, Changes: []Change{c1, c2, c3}}
+	cs2 := ChangeSet{BaseType: baseType, Changes: []Change{c3, c1, c2}}
+	cs3 := ChangeSet{BaseType: baseType, Changes: []Change{c1, c1, c2}}
+
+	if cs1.Equals(cs2) {
+		t.Errorf("Expected ordered comparison to fail")
+	}
+	if !cs1.EqualsUnordered(cs2) || !cs2.EqualsUnordered(cs1) {
+		t.Errorf("Expected unordered comparison to succeed")
+	}
+	if cs1.EqualsUnordered(cs3) {
+		t.Errorf("Expected duplicate changes not to match")
+	}
+}
+
+func TestDiffCopiesValues(t *testing.T) {
+	o1 := map[string][]int{"a": {1}}
+	o2 := map[string][]int{"a": {1}, "b": {2, 3}}
+
+	diff, err := Diff(o1, o2)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+	shared, err := DiffWithOptions(o1, o2, DiffOptions{ShareValues: true})
+	if err != nil {
+		t.Fatalf("Error in DiffWithOptions: %v", err)
+	}
+
+	o2["b"][0] = 9
+	if actual := diff.Changes[0].GetNewValue().Interface(); !reflect.DeepEqual([]int{2, 3}, actual) {
+		t.Logf("Expect: %v", []int{2, 3})
+		t.Logf("Actual: %v", actual)
+		t.Fail()
+	}
+	if actual := shared.Changes[0].GetNewValue().Interface(); !reflect.DeepEqual([]int{9, 3}, actual) {
+		t.Logf("Expect: %v", []int{9, 3})
+		t.Logf("Actual: %v", actual)
+		t.Fail()
+	}
+
+	// One ChangeSet applied to many objects must not share them.
+	target1 := map[string][]int{}
+	target2 := map[string][]int{}
+	if err := diff.Patch(&target1); err != nil {
+		t.Fatalf("Error in Patch: %v", err)
+	}
+	if err := diff.Patch(&target2); err != nil {
+		t.Fatalf("Error in Patch: %v", err)
+	}
+
+	target1["b"][1] = 7
+	expect := map[string][]int{"b": {2, 3}}
+	if !reflect.DeepEqual(expect, target2) {
+		t.Logf("Expect: %v", expect)
+		t.Logf("Actual: %v", target2)
+		t.Fail()
+	}
+	if actual := diff.Changes[0].GetNewValue().Interface(); !reflect.DeepEqual([]int{2, 3}, actual) {
+		t.Logf("Expect: %v", []int{2, 3})
+		t.Logf("Actual: %v", actual)
+		t.Fail()
+	}
+}
+
+func TestDiffSliceDeletionOrder(t *testing.T) {
+	base := []int{1, 2, 3, 4}
+	update := []int{1}
+	diff, err := Diff(base, update)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	// Deletions run from the tail as each removes the element at its index.
+	expect := ChangeSet{BaseType: reflect.TypeOf(base), Changes: []Change{
+		NewValueDeletion([]PathElement{NewIndexElem(3)}, reflect.ValueOf(4)),
+		NewValueDeletion([]PathElement{NewIndexElem(2)}, reflect.ValueOf(3)),
+		NewValueDeletion([]PathElement{NewIndexElem(1)}, reflect.ValueOf(2)),
+	}}
+	if !expect.Equals(*diff) {
+		t.Logf("Expect: %+v", expect)
+		t.Logf("Actual: %+v", diff)
+		t.Fail()
+	}
+
+	actual, err := diff.Apply(base)
+	if err != nil || !reflect.DeepEqual(update, actual) {
+		t.Logf("Expect: %+v", update)
+		t.Logf("Actual: %+v (%v)", actual, err)
+		t.Fail()
+	}
+}
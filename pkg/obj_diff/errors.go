@@ -0,0 +1,145 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+)
+
+// Sentinels for use with errors.Is, each of the error types below reports
+// itself as the matching sentinel.
+var (
+	ErrTypeMismatch    = errors.New("type mismatch")
+	ErrUnsupportedKind = errors.New("unsupported kind")
+	ErrPathNotFound    = errors.New("path not found")
+	ErrIndexOutOfRange = errors.New("index out of range")
+	ErrConflict        = errors.New("conflict")
+)
+
+// Every error type carries the path it occurred at and the index of the
+// Change being applied, which is -1 when the error did not come from a
+// Change.
+
+// Returned when a value does not have the type required at a path.
+type TypeMismatchError struct {
+	Path        []PathElement
+	ChangeIndex int
+	Expected    reflect.Type
+	Actual      reflect.Type
+}
+
+func (err TypeMismatchError) Error() string {
+	return errorPrefix(err.ChangeIndex) + fmt.Sprintf("%v is not a valid %v at %v", err.Actual, err.Expected, pathString(err.Path))
+}
+
+func (err TypeMismatchError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+// Returned when a value of a kind that can not be diffed, copied or
+// traversed is found, such as a func or chan.
+type UnsupportedKindError struct {
+	Path        []PathElement
+	ChangeIndex int
+	Kind        reflect.Kind
+	// The operation that was attempted, such as "diff" or "delete".
+	Op string
+}
+
+func (err UnsupportedKindError) Error() string {
+	return errorPrefix(err.ChangeIndex) + fmt.Sprintf("can not %v kind '%v' at %v", err.Op, err.Kind, pathString(err.Path))
+}
+
+func (err UnsupportedKindError) Is(target error) bool {
+	return target == ErrUnsupportedKind
+}
+
+// Returned when a path can not be followed because something along it, such
+// as a map key or the target of a pointer, does not exist.
+type PathNotFoundError struct {
+	// The path up to and including the missing step.
+	Path        []PathElement
+	ChangeIndex int
+	Reason      string
+}
+
+func (err PathNotFoundError) Error() string {
+	return errorPrefix(err.ChangeIndex) + fmt.Sprintf("%v at %v", err.Reason, pathString(err.Path))
+}
+
+func (err PathNotFoundError) Is(target error) bool {
+	return target == ErrPathNotFound
+}
+
+// Returned when a path addresses an index past the end of a slice or array.
+// It is also a PathNotFoundError as far as errors.Is is concerned.
+type IndexOutOfRangeError struct {
+	// The path up to and including the index.
+	Path        []PathElement
+	ChangeIndex int
+	Index       int
+	Len         int
+}
+
+func (err IndexOutOfRangeError) Error() string {
+	return errorPrefix(err.ChangeIndex) + fmt.Sprintf("index (%v) out of range (%v) at %v", err.Index, err.Len, pathString(err.Path))
+}
+
+func (err IndexOutOfRangeError) Is(target error) bool {
+	return target == ErrIndexOutOfRange || target == ErrPathNotFound
+}
+
+// Returned when the object does not match what a Change expects, such as a
+// JSON Patch test operation failing.
+type ConflictError struct {
+	Path        []PathElement
+	ChangeIndex int
+	Reason      string
+}
+
+func (err ConflictError) Error() string {
+	return errorPrefix(err.ChangeIndex) + fmt.Sprintf("%v at %v", err.Reason, pathString(err.Path))
+}
+
+func (err ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func errorPrefix(changeIndex int) string {
+	if changeIndex < 0 {
+		return ""
+	}
+	return fmt.Sprintf("change %v: ", changeIndex)
+}
+
+// Returns err with its ChangeIndex set, if it has one.
+func withChangeIndex(err error, changeIndex int) error {
+	switch e := err.(type) {
+	case TypeMismatchError:
+		e.ChangeIndex = changeIndex
+		return e
+	case UnsupportedKindError:
+		e.ChangeIndex = changeIndex
+		return e
+	case PathNotFoundError:
+		e.ChangeIndex = changeIndex
+		return e
+	case IndexOutOfRangeError:
+		e.ChangeIndex = changeIndex
+		return e
+	case ConflictError:
+		e.ChangeIndex = changeIndex
+		return e
+	}
+	return err
+}
+
+// Returns true if err means a path could not be followed.
+func isMissingParent(err error) bool {
+	return errors.Is(err, ErrPathNotFound)
+}
@@ -0,0 +1,128 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"errors"
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+	"testing"
+)
+
+type funcObj struct {
+	Name     string
+	Callback func() string
+}
+
+func TestDiffErrors(t *testing.T) {
+	if _, err := Diff(nil, 1); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expected ErrTypeMismatch, got: %v", err)
+	}
+	if _, err := Diff(1, "1"); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expected ErrTypeMismatch, got: %v", err)
+	}
+	if cs, err := Diff(nil, nil); err != nil || len(cs.Changes) != 0 {
+		t.Errorf("Expected no changes, got: %v, %v", cs, err)
+	}
+
+	callback := func() string { return "a" }
+	_, err := Diff(funcObj{"a", callback}, funcObj{"b", callback})
+	var kindErr UnsupportedKindError
+	if !errors.As(err, &kindErr) || kindErr.Kind != reflect.Func || !pathsEqual(kindErr.Path, []PathElement{NewFieldElem(1, "Callback")}) {
+		t.Errorf("Expected an UnsupportedKindError at .Callback, got: %v", err)
+	}
+
+	copied := CopyValueReflectively(funcObj{"a", callback}).(funcObj)
+	if copied.Name != "a" || copied.Callback() != "a" {
+		t.Errorf("Expected funcs to be shared by the copy, got: %+v", copied)
+	}
+	if CopyValueReflectively(nil) != nil {
+		t.Errorf("Expected a copy of nil to be nil")
+	}
+}
+
+func TestPatchErrors(t *testing.T) {
+	objType := reflect.TypeOf(Obj{})
+	nestedInt := []PathElement{NewFieldElem(10, "NestedPtr2"), NewPtrElem(), NewFieldElem(0, "Int")}
+	tests := []struct {
+		name     string
+		change   Change
+		sentinel error
+	}{
+		{
+			name:     "Wrong Value Type",
+			change:   NewValueChange([]PathElement{NewFieldElem(3, "Str")}, reflect.ValueOf("a"), reflect.ValueOf(1)),
+			sentinel: ErrTypeMismatch,
+		},
+		{
+			name:     "Missing Parent",
+			change:   NewValueChange(nestedInt, reflect.ValueOf(int64(1)), reflect.ValueOf(int64(2))),
+			sentinel: ErrPathNotFound,
+		},
+		{
+			name:     "Missing Field",
+			change:   NewValueChange([]PathElement{NewFieldElem(3, "Removed")}, reflect.ValueOf("a"), reflect.ValueOf("b")),
+			sentinel: ErrPathNotFound,
+		},
+		{
+			name:     "Index Out Of Range",
+			change:   NewValueChange([]PathElement{NewFieldElem(5, "IntList"), NewIndexElem(4)}, reflect.ValueOf(int64(1)), reflect.ValueOf(int64(2))),
+			sentinel: ErrIndexOutOfRange,
+		},
+		{
+			name:     "Wrong Key Type",
+			change:   NewValueChange([]PathElement{NewFieldElem(7, "StrIntMap"), NewKeyElem(1)}, reflect.ValueOf(int64(1)), reflect.ValueOf(int64(2))),
+			sentinel: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cs := ChangeSet{BaseType: objType, Changes: []Change{
+				NewValueChange([]PathElement{NewFieldElem(0, "Int")}, reflect.ValueOf(int32(1)), reflect.ValueOf(int32(2))),
+				test.change,
+			}}
+
+			actual := Obj{IntList: []int64{1}, StrIntMap: map[string]int64{}}
+			err := cs.PatchWithOptions(&actual, PatchOptions{ObjectPathConfig: DefaultObjectPathConfig()})
+			if err == nil {
+				t.Fatalf("Expected an error")
+			}
+			if test.sentinel != nil && !errors.Is(err, test.sentinel) {
+				t.Errorf("Expected %v, got: %v", test.sentinel, err)
+			}
+			if test.sentinel != nil && errorChangeIndex(err) != 1 {
+				t.Errorf("Expected the error to be from change 1, got: %v", err)
+			}
+		})
+	}
+
+	var obj Obj
+	if err := (ChangeSet{BaseType: reflect.TypeOf(NestObj{})}).Patch(&obj); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expected ErrTypeMismatch, got: %v", err)
+	}
+
+	if _, err := Get(Obj{}, []PathElement{NewFieldElem(5, "IntList"), NewIndexElem(0)}); !errors.Is(err, ErrPathNotFound) || !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Expected ErrIndexOutOfRange, got: %v", err)
+	}
+
+	o1, _ := buildJSONObjects()
+	_, err := ParseJSONPatchFor([]byte(`[{"op": "test", "path": "/name", "value": "b"}]`), o1)
+	var conflict ConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected a ConflictError, got: %v", err)
+	}
+}
+
+func errorChangeIndex(err error) int {
+	switch e := err.(type) {
+	case TypeMismatchError:
+		return e.ChangeIndex
+	case PathNotFoundError:
+		return e.ChangeIndex
+	case IndexOutOfRangeError:
+		return e.ChangeIndex
+	}
+	return -1
+}
@@ -0,0 +1,108 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package gentest
+
+import (
+	"github.com/walmartlabs/object-diff/pkg/obj_diff"
+	"reflect"
+	"testing"
+)
+
+func buildWidgets() []Widget {
+	return []Widget{
+		{},
+		{Name: "a", Count: 1, Ratio: 0.5, Enabled: true, Mode: "on",
+			Labels: map[string]string{"a": "1"}, Tags: []string{"x", "y"},
+			Part: Part{ID: 1, Revision: 2, Label: "p"}, Extra: 3,
+			Secret: NewSecret("s", "t1"), Copied: Copied{Values: []int{1}}, Sizes: [2]uint16{1, 2}, Handle: 1},
+		{Name: "b", Count: 2, Ratio: 0.5, Mode: "off",
+			Labels: map[string]string{"a": "2", "b": "3"}, Tags: []string{"x"},
+			Part: Part{ID: 1, Revision: 3, Label: "q"}, Spare: &Part{ID: 4}, Extra: "three",
+			Secret: NewSecret("s", "t2"), Copied: Copied{Values: []int{1, 2}}, Sizes: [2]uint16{1, 3}, Handle: 2},
+		{Name: "b", Count: 2, Spare: &Part{ID: 5, Label: "r"}, Secret: NewSecret("u", "t2")},
+	}
+}
+
+func TestGeneratedDiff(t *testing.T) {
+	widgets := buildWidgets()
+	for _, w1 := range widgets {
+		for _, w2 := range widgets {
+			expect, err := obj_diff.Diff(w1, w2)
+			if err != nil {
+				t.Fatalf("Error in Diff: %v", err)
+			}
+			actual, err := DiffWidget(w1, w2)
+			if err != nil {
+				t.Fatalf("Error in DiffWidget: %v", err)
+			}
+
+			if !expect.Equals(*actual) || !reflect.DeepEqual(expect, actual) {
+				t.Logf("Expect: %+v", expect)
+				t.Logf("Actual: %+v", actual)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestGeneratedCopy(t *testing.T) {
+	for _, w := range buildWidgets() {
+		expect := obj_diff.CopyValueReflectively(w)
+		actual := CopyWidget(w)
+		if !reflect.DeepEqual(expect, actual) || !reflect.DeepEqual(w, actual) {
+			t.Logf("Expect: %+v", expect)
+			t.Logf("Actual: %+v", actual)
+			t.Fail()
+		}
+
+		if len(w.Tags) > 0 {
+			actual.Tags[0] = "changed"
+			if w.Tags[0] == "changed" {
+				t.Errorf("Expected the copy not to share slices")
+			}
+		}
+	}
+}
+
+func TestGeneratedApply(t *testing.T) {
+	widgets := buildWidgets()
+	for _, w1 := range widgets {
+		for _, w2 := range widgets {
+			cs, err := obj_diff.Diff(w1, w2)
+			if err != nil {
+				t.Fatalf("Error in Diff: %v", err)
+			}
+
+			expect := CopyWidget(w1)
+			expectErr := cs.Patch(&expect)
+			actual := CopyWidget(w1)
+			actualErr := ApplyWidget(&actual, *cs)
+			if !reflect.DeepEqual(expectErr, actualErr) || !reflect.DeepEqual(expect, actual) {
+				t.Logf("Expect: %+v (%v)", expect, expectErr)
+				t.Logf("Actual: %+v (%v)", actual, actualErr)
+				t.Fail()
+			}
+		}
+	}
+
+	// Only basic fields are set directly.
+	cs, err := DiffPart(Part{ID: 1}, Part{ID: 2, Label: "a"})
+	if err != nil {
+		t.Fatalf("Error in DiffPart: %v", err)
+	}
+	part := Part{ID: 1, Revision: 3}
+	if err := ApplyPart(&part, *cs); err != nil || !reflect.DeepEqual(Part{ID: 2, Revision: 3, Label: "a"}, part) {
+		t.Logf("Expect: %+v", Part{ID: 2, Revision: 3, Label: "a"})
+		t.Logf("Actual: %+v (%v)", part, err)
+		t.Fail()
+	}
+
+	if err := ApplyPart(nil, *cs); err == nil {
+		t.Errorf("Expected an error applying to nil")
+	}
+	if err := ApplyWidget(&Widget{}, *cs); err == nil {
+		t.Errorf("Expected an error applying to the wrong type")
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package gentest holds types for checking the functions generated by
+// objdiff-gen against the reflective obj_diff functions.
+package gentest
+
+//go:generate go run ../../../cmd/objdiff-gen
+
+type Mode string
+
+// +objdiff:gen
+type Widget struct {
+	Name    string
+	Count   int
+	Ratio   float64
+	Enabled bool
+	Mode    Mode
+	Labels  map[string]string
+	Tags    []string
+	Part    Part
+	Spare   *Part
+	Extra   interface{}
+	Secret  Secret
+	Copied  Copied
+	Sizes   [2]uint16
+	Handle  uintptr
+}
+
+// +objdiff:gen
+type Part struct {
+	ID, Revision int64
+	Label        string
+}
+
+// Unexported fields can only be handled reflectively.
+// +objdiff:gen
+type Secret struct {
+	Name  string
+	token string
+}
+
+func NewSecret(name string, token string) Secret {
+	return Secret{Name: name, token: token}
+}
+
+// Copies are made with DeepCopyInto.
+// +objdiff:gen
+type Copied struct {
+	Values []int
+}
+
+func (in *Copied) DeepCopyInto(out *Copied) {
+	if in.Values != nil {
+		out.Values = append([]int{}, in.Values...)
+	}
+}
@@ -0,0 +1,322 @@
+// Code generated by objdiff-gen. DO NOT EDIT.
+
+package gentest
+
+import (
+	"reflect"
+
+	"github.com/walmartlabs/object-diff/pkg/obj_diff"
+	"github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+)
+
+// DiffCopied computes the change set between a and b, the same as obj_diff.Diff.
+func DiffCopied(a Copied, b Copied) (*obj_diff.ChangeSet, error) {
+	cs := &obj_diff.ChangeSet{BaseType: reflect.TypeOf((*Copied)(nil)).Elem()}
+	return cs, diffCopied(cs, []helpers.PathElement{}, &a, &b)
+}
+
+func diffCopied(cs *obj_diff.ChangeSet, path []helpers.PathElement, a *Copied, b *Copied) error {
+	if err := obj_diff.DiffValuesAt(cs, append(path[:len(path):len(path)], helpers.NewFieldElem(0, "Values")),
+		reflect.ValueOf(&a.Values).Elem(), reflect.ValueOf(&b.Values).Elem(), obj_diff.DiffOptions{}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CopyCopied returns a deep copy of in, the same as obj_diff.CopyValueReflectively.
+func CopyCopied(in Copied) Copied {
+	return obj_diff.CopyValueReflectively(in).(Copied)
+}
+
+// ApplyCopied patches obj with the changes in cs, the same as cs.Patch(obj).
+func ApplyCopied(obj *Copied, cs obj_diff.ChangeSet) error {
+	if obj == nil || cs.BaseType != reflect.TypeOf((*Copied)(nil)).Elem() || !applyCopiedFields(obj, cs.Changes, false) {
+		return cs.Patch(obj)
+	}
+	applyCopiedFields(obj, cs.Changes, true)
+	return nil
+}
+
+// Sets the basic fields of obj from changes if set is true. Returns false,
+// without setting anything, if any change must be applied reflectively.
+func applyCopiedFields(obj *Copied, changes []helpers.Change, set bool) bool {
+	for _, change := range changes {
+		path := change.GetPath()
+		if len(path) != 1 || change.IsDeletion() || !change.GetNewValue().IsValid() {
+			return false
+		}
+		switch path[0].GetName() {
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// DiffPart computes the change set between a and b, the same as obj_diff.Diff.
+func DiffPart(a Part, b Part) (*obj_diff.ChangeSet, error) {
+	cs := &obj_diff.ChangeSet{BaseType: reflect.TypeOf((*Part)(nil)).Elem()}
+	return cs, diffPart(cs, []helpers.PathElement{}, &a, &b)
+}
+
+func diffPart(cs *obj_diff.ChangeSet, path []helpers.PathElement, a *Part, b *Part) error {
+	if a.ID != b.ID {
+		cs.AddPathChange(append(path[:len(path):len(path)], helpers.NewFieldElem(0, "ID")), reflect.ValueOf(a.ID), reflect.ValueOf(b.ID))
+	}
+	if a.Revision != b.Revision {
+		cs.AddPathChange(append(path[:len(path):len(path)], helpers.NewFieldElem(1, "Revision")), reflect.ValueOf(a.Revision), reflect.ValueOf(b.Revision))
+	}
+	if a.Label != b.Label {
+		cs.AddPathChange(append(path[:len(path):len(path)], helpers.NewFieldElem(2, "Label")), reflect.ValueOf(a.Label), reflect.ValueOf(b.Label))
+	}
+	return nil
+}
+
+// CopyPart returns a deep copy of in, the same as obj_diff.CopyValueReflectively.
+func CopyPart(in Part) Part {
+	var out Part
+	out.ID = in.ID
+	out.Revision = in.Revision
+	out.Label = in.Label
+	return out
+}
+
+// ApplyPart patches obj with the changes in cs, the same as cs.Patch(obj).
+func ApplyPart(obj *Part, cs obj_diff.ChangeSet) error {
+	if obj == nil || cs.BaseType != reflect.TypeOf((*Part)(nil)).Elem() || !applyPartFields(obj, cs.Changes, false) {
+		return cs.Patch(obj)
+	}
+	applyPartFields(obj, cs.Changes, true)
+	return nil
+}
+
+// Sets the basic fields of obj from changes if set is true. Returns false,
+// without setting anything, if any change must be applied reflectively.
+func applyPartFields(obj *Part, changes []helpers.Change, set bool) bool {
+	for _, change := range changes {
+		path := change.GetPath()
+		if len(path) != 1 || change.IsDeletion() || !change.GetNewValue().IsValid() {
+			return false
+		}
+		switch path[0].GetName() {
+		case "ID":
+			value, ok := change.GetNewValue().Interface().(int64)
+			if !ok {
+				return false
+			}
+			if set {
+				obj.ID = value
+			}
+		case "Revision":
+			value, ok := change.GetNewValue().Interface().(int64)
+			if !ok {
+				return false
+			}
+			if set {
+				obj.Revision = value
+			}
+		case "Label":
+			value, ok := change.GetNewValue().Interface().(string)
+			if !ok {
+				return false
+			}
+			if set {
+				obj.Label = value
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// DiffSecret computes the change set between a and b, the same as obj_diff.Diff.
+func DiffSecret(a Secret, b Secret) (*obj_diff.ChangeSet, error) {
+	cs := &obj_diff.ChangeSet{BaseType: reflect.TypeOf((*Secret)(nil)).Elem()}
+	return cs, diffSecret(cs, []helpers.PathElement{}, &a, &b)
+}
+
+func diffSecret(cs *obj_diff.ChangeSet, path []helpers.PathElement, a *Secret, b *Secret) error {
+	return obj_diff.DiffValuesAt(cs, path, reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem(), obj_diff.DiffOptions{})
+}
+
+// CopySecret returns a deep copy of in, the same as obj_diff.CopyValueReflectively.
+func CopySecret(in Secret) Secret {
+	return obj_diff.CopyValueReflectively(in).(Secret)
+}
+
+// ApplySecret patches obj with the changes in cs, the same as cs.Patch(obj).
+func ApplySecret(obj *Secret, cs obj_diff.ChangeSet) error {
+	if obj == nil || cs.BaseType != reflect.TypeOf((*Secret)(nil)).Elem() || !applySecretFields(obj, cs.Changes, false) {
+		return cs.Patch(obj)
+	}
+	applySecretFields(obj, cs.Changes, true)
+	return nil
+}
+
+// Sets the basic fields of obj from changes if set is true. Returns false,
+// without setting anything, if any change must be applied reflectively.
+func applySecretFields(obj *Secret, changes []helpers.Change, set bool) bool {
+	for _, change := range changes {
+		path := change.GetPath()
+		if len(path) != 1 || change.IsDeletion() || !change.GetNewValue().IsValid() {
+			return false
+		}
+		switch path[0].GetName() {
+		case "Name":
+			value, ok := change.GetNewValue().Interface().(string)
+			if !ok {
+				return false
+			}
+			if set {
+				obj.Name = value
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// DiffWidget computes the change set between a and b, the same as obj_diff.Diff.
+func DiffWidget(a Widget, b Widget) (*obj_diff.ChangeSet, error) {
+	cs := &obj_diff.ChangeSet{BaseType: reflect.TypeOf((*Widget)(nil)).Elem()}
+	return cs, diffWidget(cs, []helpers.PathElement{}, &a, &b)
+}
+
+func diffWidget(cs *obj_diff.ChangeSet, path []helpers.PathElement, a *Widget, b *Widget) error {
+	if a.Name != b.Name {
+		cs.AddPathChange(append(path[:len(path):len(path)], helpers.NewFieldElem(0, "Name")), reflect.ValueOf(a.Name), reflect.ValueOf(b.Name))
+	}
+	if a.Count != b.Count {
+		cs.AddPathChange(append(path[:len(path):len(path)], helpers.NewFieldElem(1, "Count")), reflect.ValueOf(a.Count), reflect.ValueOf(b.Count))
+	}
+	if a.Ratio != b.Ratio {
+		cs.AddPathChange(append(path[:len(path):len(path)], helpers.NewFieldElem(2, "Ratio")), reflect.ValueOf(a.Ratio), reflect.ValueOf(b.Ratio))
+	}
+	if a.Enabled != b.Enabled {
+		cs.AddPathChange(append(path[:len(path):len(path)], helpers.NewFieldElem(3, "Enabled")), reflect.ValueOf(a.Enabled), reflect.ValueOf(b.Enabled))
+	}
+	if err := obj_diff.DiffValuesAt(cs, append(path[:len(path):len(path)], helpers.NewFieldElem(4, "Mode")),
+		reflect.ValueOf(&a.Mode).Elem(), reflect.ValueOf(&b.Mode).Elem(), obj_diff.DiffOptions{}); err != nil {
+		return err
+	}
+	if err := obj_diff.DiffValuesAt(cs, append(path[:len(path):len(path)], helpers.NewFieldElem(5, "Labels")),
+		reflect.ValueOf(&a.Labels).Elem(), reflect.ValueOf(&b.Labels).Elem(), obj_diff.DiffOptions{}); err != nil {
+		return err
+	}
+	if err := obj_diff.DiffValuesAt(cs, append(path[:len(path):len(path)], helpers.NewFieldElem(6, "Tags")),
+		reflect.ValueOf(&a.Tags).Elem(), reflect.ValueOf(&b.Tags).Elem(), obj_diff.DiffOptions{}); err != nil {
+		return err
+	}
+	if err := diffPart(cs, append(path[:len(path):len(path)], helpers.NewFieldElem(7, "Part")), &a.Part, &b.Part); err != nil {
+		return err
+	}
+	if err := obj_diff.DiffValuesAt(cs, append(path[:len(path):len(path)], helpers.NewFieldElem(8, "Spare")),
+		reflect.ValueOf(&a.Spare).Elem(), reflect.ValueOf(&b.Spare).Elem(), obj_diff.DiffOptions{}); err != nil {
+		return err
+	}
+	if err := obj_diff.DiffValuesAt(cs, append(path[:len(path):len(path)], helpers.NewFieldElem(9, "Extra")),
+		reflect.ValueOf(&a.Extra).Elem(), reflect.ValueOf(&b.Extra).Elem(), obj_diff.DiffOptions{}); err != nil {
+		return err
+	}
+	if err := diffSecret(cs, append(path[:len(path):len(path)], helpers.NewFieldElem(10, "Secret")), &a.Secret, &b.Secret); err != nil {
+		return err
+	}
+	if err := diffCopied(cs, append(path[:len(path):len(path)], helpers.NewFieldElem(11, "Copied")), &a.Copied, &b.Copied); err != nil {
+		return err
+	}
+	if err := obj_diff.DiffValuesAt(cs, append(path[:len(path):len(path)], helpers.NewFieldElem(12, "Sizes")),
+		reflect.ValueOf(&a.Sizes).Elem(), reflect.ValueOf(&b.Sizes).Elem(), obj_diff.DiffOptions{}); err != nil {
+		return err
+	}
+	if a.Handle != b.Handle {
+		cs.AddPathChange(append(path[:len(path):len(path)], helpers.NewFieldElem(13, "Handle")), reflect.ValueOf(a.Handle), reflect.ValueOf(b.Handle))
+	}
+	return nil
+}
+
+// CopyWidget returns a deep copy of in, the same as obj_diff.CopyValueReflectively.
+func CopyWidget(in Widget) Widget {
+	var out Widget
+	out.Name = in.Name
+	out.Count = in.Count
+	out.Ratio = in.Ratio
+	out.Enabled = in.Enabled
+	reflect.ValueOf(&out.Mode).Elem().Set(obj_diff.CopyReflectValue(reflect.ValueOf(&in.Mode).Elem()))
+	reflect.ValueOf(&out.Labels).Elem().Set(obj_diff.CopyReflectValue(reflect.ValueOf(&in.Labels).Elem()))
+	reflect.ValueOf(&out.Tags).Elem().Set(obj_diff.CopyReflectValue(reflect.ValueOf(&in.Tags).Elem()))
+	out.Part = CopyPart(in.Part)
+	reflect.ValueOf(&out.Spare).Elem().Set(obj_diff.CopyReflectValue(reflect.ValueOf(&in.Spare).Elem()))
+	reflect.ValueOf(&out.Extra).Elem().Set(obj_diff.CopyReflectValue(reflect.ValueOf(&in.Extra).Elem()))
+	out.Secret = CopySecret(in.Secret)
+	out.Copied = CopyCopied(in.Copied)
+	reflect.ValueOf(&out.Sizes).Elem().Set(obj_diff.CopyReflectValue(reflect.ValueOf(&in.Sizes).Elem()))
+	out.Handle = in.Handle
+	return out
+}
+
+// ApplyWidget patches obj with the changes in cs, the same as cs.Patch(obj).
+func ApplyWidget(obj *Widget, cs obj_diff.ChangeSet) error {
+	if obj == nil || cs.BaseType != reflect.TypeOf((*Widget)(nil)).Elem() || !applyWidgetFields(obj, cs.Changes, false) {
+		return cs.Patch(obj)
+	}
+	applyWidgetFields(obj, cs.Changes, true)
+	return nil
+}
+
+// Sets the basic fields of obj from changes if set is true. Returns false,
+// without setting anything, if any change must be applied reflectively.
+func applyWidgetFields(obj *Widget, changes []helpers.Change, set bool) bool {
+	for _, change := range changes {
+		path := change.GetPath()
+		if len(path) != 1 || change.IsDeletion() || !change.GetNewValue().IsValid() {
+			return false
+		}
+		switch path[0].GetName() {
+		case "Name":
+			value, ok := change.GetNewValue().Interface().(string)
+			if !ok {
+				return false
+			}
+			if set {
+				obj.Name = value
+			}
+		case "Count":
+			value, ok := change.GetNewValue().Interface().(int)
+			if !ok {
+				return false
+			}
+			if set {
+				obj.Count = value
+			}
+		case "Ratio":
+			value, ok := change.GetNewValue().Interface().(float64)
+			if !ok {
+				return false
+			}
+			if set {
+				obj.Ratio = value
+			}
+		case "Enabled":
+			value, ok := change.GetNewValue().Interface().(bool)
+			if !ok {
+				return false
+			}
+			if set {
+				obj.Enabled = value
+			}
+		case "Handle":
+			value, ok := change.GetNewValue().Interface().(uintptr)
+			if !ok {
+				return false
+			}
+			if set {
+				obj.Handle = value
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
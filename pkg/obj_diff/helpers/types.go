@@ -5,6 +5,7 @@
 package helpers
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
@@ -104,13 +105,15 @@ func (c change) IsDeletion() bool {
 // Compare this change against another change. Returns true if they
 // are the same. Currently only used in testing.
 func (c change) Equals(that Change) bool {
-	if c.IsDeletion() != that.IsDeletion() {
+	if c.IsDeletion() != that.IsDeletion() || c.IsAddition() != that.IsAddition() {
 		return false
 	}
 
-	thisValue := c.GetNewValue().Interface()
-	thatValue := that.GetNewValue().Interface()
-	if !reflect.DeepEqual(thisValue, thatValue) {
+	thisValue := c.GetNewValue()
+	thatValue := that.GetNewValue()
+	if thisValue.IsValid() != thatValue.IsValid() {
+		return false
+	} else if thisValue.IsValid() && !reflect.DeepEqual(thisValue.Interface(), thatValue.Interface()) {
 		return false
 	}
 
@@ -220,13 +223,11 @@ func (pe pathElement) Equals(other PathElement) bool {
 
 	thisKey := pe.GetKey()
 	thatKey := other.GetKey()
-	if thisKey.IsValid() || thatKey.IsValid() {
-		if thisKey.IsValid() && thatKey.IsValid() {
-			if !reflect.DeepEqual(thisKey.Interface(), thatKey.Interface()) {
-				return false
-			}
-		}
-	} else
+	if thisKey.IsValid() != thatKey.IsValid() {
+		return false
+	} else if thisKey.IsValid() && !reflect.DeepEqual(thisKey.Interface(), thatKey.Interface()) {
+		return false
+	}
 
 	if pe.IsPointer() != other.IsPointer() {
 		return false
@@ -237,6 +238,11 @@ func (pe pathElement) Equals(other PathElement) bool {
 
 func (pe pathElement) String() string {
 	if pe.key != nil {
+		// Keys are JSON encoded so the string can be parsed back. Keys JSON
+		// can not encode, such as NaN and infinite floats, are formatted.
+		if encoded, err := json.Marshal(pe.key); err == nil {
+			return fmt.Sprintf("{%s}", encoded)
+		}
 		return fmt.Sprintf("{%v}", pe.key)
 	}
 
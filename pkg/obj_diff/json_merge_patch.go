@@ -0,0 +1,187 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+	"strings"
+)
+
+// Converts this ChangeSet into an RFC 7386 JSON Merge Patch document
+// against the JSON encoding of the BaseType. Deletions are represented as
+// null. Merge patch can not express changes to individual slice or array
+// elements, changes below map keys which are not strings or map values set
+// to null, which would delete them instead, if there are any an error
+// listing them is returned. See MergePatchFor to replace whole slices and
+// arrays instead.
+func (cs ChangeSet) MergePatch() ([]byte, error) {
+	return cs.mergePatch(reflect.Value{})
+}
+
+// Converts this ChangeSet into an RFC 7386 JSON Merge Patch document, as
+// MergePatch, for patching base. Changes to slice and array elements are
+// expressed by replacing the whole slice or array with its value after this
+// ChangeSet has been applied to base. Map keys which are not strings are
+// formatted the same way encoding/json formats them. The base itself is not
+// modified.
+func (cs ChangeSet) MergePatchFor(base interface{}) ([]byte, error) {
+	patched, err := cs.Apply(base)
+	if err != nil {
+		return nil, err
+	}
+
+	patchedVal := reflect.ValueOf(patched)
+	if patchedVal.Type() != cs.BaseType {
+		// Apply returns the same type as base, paths start at the BaseType.
+		patchedVal = patchedVal.Elem()
+	}
+	return cs.mergePatch(patchedVal)
+}
+
+// Builds the merge patch, if patched is valid it is used to replace slices
+// and arrays.
+func (cs ChangeSet) mergePatch(patched reflect.Value) ([]byte, error) {
+	doc := map[string]interface{}{}
+	inexpressible := []string{}
+	for _, change := range cs.Changes {
+		path := change.GetPath()
+		// Changes within a slice or array are addressed by the slice or
+		// array itself.
+		sliceAt := firstSliceIndex(cs.BaseType, path)
+		segmentPath := path
+		if sliceAt >= 0 {
+			segmentPath = path[:sliceAt]
+		}
+		segments, err := JSONSegments(cs.BaseType, segmentPath)
+		if err != nil {
+			return nil, err
+		}
+
+		reason := ""
+		for _, pe := range path {
+			if key := pe.GetKey(); key.IsValid() && key.Kind() != reflect.String && !patched.IsValid() {
+				reason = fmt.Sprintf("%v has a map key of type %v", change.PathString(), key.Type())
+				break
+			}
+		}
+		if len(segments) == 0 {
+			reason = fmt.Sprintf("%v replaces the whole object", change.PathString())
+		}
+
+		var value interface{}
+		if sliceAt >= 0 {
+			if !patched.IsValid() {
+				reason = fmt.Sprintf("%v changes a slice or array element", change.PathString())
+			} else if sliceAt == 0 {
+				// The BaseType is a slice, every change is within it so the
+				// patch is the whole patched value.
+				return json.Marshal(patched.Interface())
+			} else {
+				// Replace the whole slice or array containing the change.
+				slice, err := lookupPath(patched, path[:sliceAt])
+				if err != nil {
+					return nil, err
+				}
+				if value, err = toJSONValue(slice); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			if !change.IsDeletion() {
+				if value, err = toJSONValue(change.GetNewValue()); err != nil {
+					return nil, err
+				}
+			}
+			if value == nil && setsMapValueToNull(path, change.IsDeletion()) {
+				reason = fmt.Sprintf("%v sets a map value to null", change.PathString())
+			}
+		}
+
+		if len(reason) > 0 {
+			inexpressible = append(inexpressible, reason)
+			continue
+		}
+		if err := setMergePatchMember(doc, segments, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(inexpressible) > 0 {
+		return nil, fmt.Errorf("changes can not be expressed as a merge patch: %v", strings.Join(inexpressible, ", "))
+	}
+	return json.Marshal(doc)
+}
+
+// Returns the position in path of the first index into a slice or array,
+// or -1.
+func firstSliceIndex(baseType reflect.Type, path []PathElement) int {
+	currType := baseType
+	for i, pe := range path {
+		if currType.Kind() == reflect.Slice || currType.Kind() == reflect.Array {
+			return i
+		}
+
+		nextType, err := typeAtPath(currType, []PathElement{pe})
+		if err != nil {
+			return -1
+		}
+		currType = nextType
+	}
+
+	return -1
+}
+
+// Returns true if a null at path is a map value kept as null, which a merge
+// patch would delete instead. Only a deletion of the map key itself removes
+// it, a deleted pointer in a map leaves a nil pointer behind.
+func setsMapValueToNull(path []PathElement, isDeletion bool) bool {
+	end := len(path)
+	for end > 0 && path[end-1].IsPointer() {
+		end--
+	}
+	if end == 0 || !path[end-1].GetKey().IsValid() {
+		return false
+	}
+	return !isDeletion || end < len(path)
+}
+
+// Encodes value as JSON and decodes it into a generic value.
+func toJSONValue(value reflect.Value) (interface{}, error) {
+	if !value.IsValid() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	err = json.Unmarshal(data, &decoded)
+	return decoded, err
+}
+
+// Sets the member at segments to value, creating objects along the way.
+func setMergePatchMember(doc map[string]interface{}, segments []JSONSegment, value interface{}) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("can not set a merge patch member without a path")
+	}
+
+	curr := doc
+	for _, seg := range segments[:len(segments)-1] {
+		next, ok := curr[seg.Name].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			curr[seg.Name] = next
+		}
+		curr = next
+	}
+
+	curr[segments[len(segments)-1].Name] = value
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	o1, o2 := buildJSONObjects()
+	o2.Spec.Containers = o1.Spec.Containers
+	o2.Spec.Ports = o1.Spec.Ports
+	o2.Spec.Selector = o1.Spec.Selector
+
+	diff, err := Diff(&o1, &o2)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	actual, err := diff.MergePatch()
+	if err != nil {
+		t.Fatalf("Error in MergePatch: %v", err)
+	}
+
+	expect := `{"Untagged":2,"annotations":{"a/b":"3","c~d":null,"e":"4"},"name":"b","spec":{"paused":null,"replicas":5}}`
+	if expect != string(actual) {
+		t.Logf("Expect: %s", expect)
+		t.Logf("Actual: %s", actual)
+		t.Fail()
+	}
+}
+
+func TestMergePatchSlices(t *testing.T) {
+	o1, o2 := buildJSONObjects()
+	diff, err := Diff(o1, o2)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	if _, err := diff.MergePatch(); err == nil {
+		t.Fatalf("Expected an error for slice and int key changes")
+	}
+
+	actual, err := diff.MergePatchFor(o1)
+	if err != nil {
+		t.Fatalf("Error in MergePatchFor: %v", err)
+	}
+
+	expect := `{"Untagged":2,"annotations":{"a/b":"3","c~d":null,"e":"4"},"name":"b",` +
+		`"spec":{"containers":[{"image":"a:2","name":"a"},{"image":"b:1","name":"b"},{"image":"c:1","name":"c"}],` +
+		`"paused":null,"ports":[80],"replicas":5,"selector":{"1":"b"}}}`
+	if expect != string(actual) {
+		t.Logf("Expect: %s", expect)
+		t.Logf("Actual: %s", actual)
+		t.Fail()
+	}
+}
+
+func TestMergePatchRootSlice(t *testing.T) {
+	diff, err := Diff([]int{1, 2}, []int{1, 3})
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	actual, err := diff.MergePatchFor([]int{1, 2})
+	if err != nil {
+		t.Fatalf("Error in MergePatchFor: %v", err)
+	}
+	if expect := `[1,3]`; expect != string(actual) {
+		t.Logf("Expect: %s", expect)
+		t.Logf("Actual: %s", actual)
+		t.Fail()
+	}
+
+	if _, err := diff.MergePatch(); err == nil {
+		t.Errorf("Expected an error for a slice element change without a base")
+	}
+}
+
+type mergePatchArrays struct {
+	Digests [2]string          `json:"digests"`
+	Bytes   [2]byte            `json:"bytes"`
+	Labels  map[string]*string `json:"labels"`
+}
+
+func TestMergePatchArrays(t *testing.T) {
+	base := mergePatchArrays{Digests: [2]string{"a", "b"}, Bytes: [2]byte{1, 2}}
+	update := mergePatchArrays{Digests: [2]string{"a", "c"}, Bytes: [2]byte{1, 3}}
+	diff, err := Diff(base, update)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	if _, err := diff.MergePatch(); err == nil {
+		t.Errorf("Expected an error for array element changes without a base")
+	}
+
+	actual, err := diff.MergePatchFor(base)
+	if err != nil {
+		t.Fatalf("Error in MergePatchFor: %v", err)
+	}
+	if expect := `{"bytes":[1,3],"digests":["a","c"]}`; expect != string(actual) {
+		t.Logf("Expect: %s", expect)
+		t.Logf("Actual: %s", actual)
+		t.Fail()
+	}
+}
+
+func TestMergePatchNullMapValue(t *testing.T) {
+	value := "a"
+	base := mergePatchArrays{Labels: map[string]*string{"a": &value}}
+	update := mergePatchArrays{Labels: map[string]*string{"a": nil, "b": nil}}
+	diff, err := Diff(base, update)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	if _, err := diff.MergePatch(); err == nil || !strings.Contains(err.Error(), `{"a"}* sets a map value to null`) {
+		t.Errorf("Expected an error for map values set to null, got %v", err)
+	}
+	if _, err := diff.MergePatchFor(base); err == nil {
+		t.Errorf("Expected an error for map values set to null with a base")
+	}
+
+	removed, err := Diff(base, mergePatchArrays{Labels: map[string]*string{}})
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+	actual, err := removed.MergePatch()
+	if err != nil {
+		t.Fatalf("Error in MergePatch: %v", err)
+	}
+	if expect := `{"labels":{"a":null}}`; expect != string(actual) {
+		t.Logf("Expect: %s", expect)
+		t.Logf("Actual: %s", actual)
+		t.Fail()
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+)
+
+// JSON Patch (RFC 6902) operations.
+const (
+	JSONPatchAdd     = "add"
+	JSONPatchRemove  = "remove"
+	JSONPatchReplace = "replace"
+	JSONPatchMove    = "move"
+	JSONPatchCopy    = "copy"
+	JSONPatchTest    = "test"
+)
+
+// A single RFC 6902 JSON Patch operation.
+type JSONPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Converts this ChangeSet into a list of RFC 6902 JSON Patch operations
+// against the JSON encoding of the BaseType. Additions, deletions and
+// changes become add, remove and replace operations respectively. Returns
+// an error if a change can not be addressed in the JSON encoding, such as
+// a field tagged with json:"-" or an index into a []byte.
+//
+// Every map and slice changed is assumed to exist in the JSON. A nil map or
+// slice is null and an empty one tagged with omitempty is missing, so
+// changes inside them need JSONPatchFor.
+func (cs ChangeSet) JSONPatch() ([]JSONPatchOperation, error) {
+	ops := []JSONPatchOperation{}
+	for _, change := range cs.Changes {
+		op, err := jsonPatchOperation(cs, change)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+
+	return ops, nil
+}
+
+// Converts this ChangeSet into a JSON Patch document, suitable for sending
+// to the Kubernetes API with types.JSONPatchType.
+func (cs ChangeSet) MarshalJSONPatch() ([]byte, error) {
+	ops, err := cs.JSONPatch()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(ops)
+}
+
+// Converts this ChangeSet into a list of RFC 6902 JSON Patch operations
+// against the JSON encoding of obj, the object it patches. Changes inside a
+// map or slice which is null or missing in the JSON become a single add of
+// the whole map or slice with its new contents, and an omitempty map or
+// slice emptied by the changes is removed. The obj itself is never
+// modified.
+func (cs ChangeSet) JSONPatchFor(obj interface{}) ([]JSONPatchOperation, error) {
+	objVal := reflect.ValueOf(obj)
+	if !objVal.IsValid() {
+		return nil, fmt.Errorf("can not convert to a JSON Patch for nil")
+	}
+
+	current := reflect.New(objVal.Type()).Elem()
+	current.Set(CopyReflectValue(objVal))
+
+	ops := []JSONPatchOperation{}
+	for i := 0; i < len(cs.Changes); i++ {
+		change := cs.Changes[i]
+		if container, missing := missingJSONContainer(cs.BaseType, current, change); missing {
+			// The following changes inside the same container are folded
+			// into its value.
+			end := i + 1
+			for end < len(cs.Changes) && hasPathPrefix(cs.Changes[end].GetPath(), container) {
+				end++
+			}
+			if err := (ChangeSet{BaseType: cs.BaseType, Changes: cs.Changes[i:end]}).applyChanges(current); err != nil {
+				return nil, err
+			}
+			op, err := jsonPatchAddContainer(cs.BaseType, current, container)
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, op)
+			i = end - 1
+			continue
+		}
+
+		op, err := jsonPatchOperation(cs, change)
+		if err != nil {
+			return nil, err
+		}
+		if err := (ChangeSet{BaseType: cs.BaseType, Changes: []Change{change}}).applyChanges(current); err != nil {
+			return nil, err
+		}
+		if change.IsDeletion() {
+			path := change.GetPath()
+			if len(path) > 0 && isMissingJSONContainer(cs.BaseType, current, path[:len(path)-1]) {
+				// The last element is gone, so is the container.
+				op, err = jsonPatchRemoveContainer(cs.BaseType, path[:len(path)-1])
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		ops = append(ops, op)
+	}
+
+	return ops, nil
+}
+
+// Converts this ChangeSet into a JSON Patch document against the JSON
+// encoding of obj, see JSONPatchFor.
+func (cs ChangeSet) MarshalJSONPatchFor(obj interface{}) ([]byte, error) {
+	ops, err := cs.JSONPatchFor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(ops)
+}
+
+// Returns the path of the outermost map or slice containing change which is
+// missing from the JSON of current. Deletions are never inside one.
+func missingJSONContainer(baseType reflect.Type, current reflect.Value, change Change) ([]PathElement, bool) {
+	if change.IsDeletion() {
+		return nil, false
+	}
+
+	path := change.GetPath()
+	for i := 0; i < len(path); i++ {
+		if isMissingJSONContainer(baseType, current, path[:i]) {
+			return path[:i], true
+		}
+	}
+	return nil, false
+}
+
+// Returns true if the value at path in current is a map or slice encoded as
+// null, or an empty one omitted from its struct.
+func isMissingJSONContainer(baseType reflect.Type, current reflect.Value, path []PathElement) bool {
+	value, err := lookupPath(current, path)
+	if err != nil {
+		return false
+	}
+	if value.Kind() != reflect.Map && value.Kind() != reflect.Slice {
+		return false
+	}
+	if value.IsNil() {
+		return true
+	}
+	if value.Len() > 0 || len(path) == 0 || len(path[len(path)-1].GetName()) == 0 {
+		return false
+	}
+
+	segments, err := JSONSegments(baseType, path)
+	return err == nil && len(segments) > 0 && segments[len(segments)-1].OmitEmpty
+}
+
+// Returns an add of the whole value at path in current.
+func jsonPatchAddContainer(baseType reflect.Type, current reflect.Value, path []PathElement) (op JSONPatchOperation, err error) {
+	segments, err := JSONSegments(baseType, path)
+	if err != nil {
+		return op, err
+	}
+	value, err := lookupPath(current, path)
+	if err != nil {
+		return op, err
+	}
+
+	op = JSONPatchOperation{Op: JSONPatchAdd, Path: jsonPointer(segments)}
+	op.Value, err = json.Marshal(value.Interface())
+	if err != nil {
+		return op, fmt.Errorf("can not encode value at %v: %v", pathString(path), err)
+	}
+	return op, nil
+}
+
+// Returns a remove of the value at path.
+func jsonPatchRemoveContainer(baseType reflect.Type, path []PathElement) (op JSONPatchOperation, err error) {
+	segments, err := JSONSegments(baseType, path)
+	if err != nil {
+		return op, err
+	}
+	return JSONPatchOperation{Op: JSONPatchRemove, Path: jsonPointer(segments)}, nil
+}
+
+func jsonPatchOperation(cs ChangeSet, change Change) (op JSONPatchOperation, err error) {
+	path := change.GetPath()
+	segments, err := JSONSegments(cs.BaseType, path)
+	if err != nil {
+		return op, err
+	}
+	op.Path = jsonPointer(segments)
+
+	if change.IsDeletion() {
+		// A deleted pointer or field is still encoded as null unless it
+		// is omitted when empty.
+		last := len(segments) - 1
+		isMember := len(path) > 0 && (path[len(path)-1].IsPointer() || len(path[len(path)-1].GetName()) > 0)
+		if isMember && last >= 0 && !segments[last].OmitEmpty {
+			op.Op = JSONPatchReplace
+			op.Value = json.RawMessage("null")
+			return op, nil
+		}
+
+		op.Op = JSONPatchRemove
+		return op, nil
+	}
+
+	// Fields omitted when empty are missing from the JSON before a change
+	// from an empty value, and should be missing after a change to one.
+	last := len(segments) - 1
+	isField := len(path) > 0 && len(path[len(path)-1].GetName()) > 0
+	omittable := isField && last >= 0 && segments[last].OmitEmpty
+	if change.IsAddition() || (omittable && isEmptyJSONValue(change.GetOldValue())) {
+		op.Op = JSONPatchAdd
+	} else if omittable && isEmptyJSONValue(change.GetNewValue()) {
+		op.Op = JSONPatchRemove
+		return op, nil
+	} else {
+		op.Op = JSONPatchReplace
+	}
+
+	newValue := change.GetNewValue()
+	if !newValue.IsValid() {
+		op.Value = json.RawMessage("null")
+		return op, nil
+	}
+
+	op.Value, err = json.Marshal(newValue.Interface())
+	if err != nil {
+		return op, fmt.Errorf("can not encode value at %v: %v", change.PathString(), err)
+	}
+	return op, nil
+}
+
+// Returns true if encoding/json considers v empty for omitempty, an invalid
+// value is a nil interface.
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,351 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+	"strings"
+)
+
+// Parses an RFC 6902 JSON Patch document into a ChangeSet for baseType.
+// JSON Pointers are resolved through the json tags of baseType and values
+// are decoded into the types found there. Only add, remove and replace are
+// supported as test, move and copy depend on the object being patched, see
+// ParseJSONPatchFor.
+func ParseJSONPatch(patch []byte, baseType reflect.Type) (*ChangeSet, error) {
+	return parseJSONPatch(patch, baseType, reflect.Value{})
+}
+
+// Parses an RFC 6902 JSON Patch document into a ChangeSet which can be
+// used to patch obj. All operations are supported, the operations are
+// evaluated in order against a copy of obj so that test, move and copy see
+// the result of the operations before them. Returns an error if any
+// operation can not be applied, including a failed test. The obj itself is
+// never modified.
+func ParseJSONPatchFor(patch []byte, obj interface{}) (*ChangeSet, error) {
+	objVal := reflect.ValueOf(obj)
+	if !objVal.IsValid() {
+		return nil, fmt.Errorf("can not parse a JSON Patch for nil")
+	}
+
+	current := reflect.New(objVal.Type()).Elem()
+	current.Set(CopyReflectValue(objVal))
+	return parseJSONPatch(patch, objVal.Type(), current)
+}
+
+func parseJSONPatch(patch []byte, baseType reflect.Type, current reflect.Value) (*ChangeSet, error) {
+	var ops []JSONPatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, err
+	}
+
+	p := jsonPatchParser{cs: &ChangeSet{BaseType: baseType}, current: current}
+	for i, op := range ops {
+		if err := p.parseOperation(op); err != nil {
+			return nil, fmt.Errorf("operation %v (%v %v): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return p.cs, nil
+}
+
+type jsonPatchParser struct {
+	cs *ChangeSet
+	// The object after each operation has been applied, invalid if there
+	// is no object to evaluate against.
+	current reflect.Value
+}
+
+func (p *jsonPatchParser) hasObject() bool {
+	return p.current.IsValid()
+}
+
+func (p *jsonPatchParser) parseOperation(op JSONPatchOperation) error {
+	var changes []Change
+	var err error
+	switch op.Op {
+	case JSONPatchAdd:
+		changes, err = p.parseAdd(op.Path, op.Value)
+	case JSONPatchRemove:
+		changes, err = p.parseRemove(op.Path)
+	case JSONPatchReplace:
+		changes, err = p.parseReplace(op.Path, op.Value)
+	case JSONPatchTest:
+		err = p.parseTest(op.Path, op.Value)
+	case JSONPatchCopy:
+		changes, err = p.parseCopy(op.From, op.Path)
+	case JSONPatchMove:
+		changes, err = p.parseMove(op.From, op.Path)
+	default:
+		err = fmt.Errorf("unknown operation")
+	}
+	if err != nil {
+		return err
+	}
+
+	return p.commit(changes)
+}
+
+// Records changes and applies them to the current object.
+func (p *jsonPatchParser) commit(changes []Change) error {
+	p.cs.Changes = append(p.cs.Changes, changes...)
+	if !p.hasObject() || len(changes) == 0 {
+		return nil
+	}
+
+	return ChangeSet{BaseType: p.cs.BaseType, Changes: changes}.applyChanges(p.current)
+}
+
+// The resolved target of a JSON Pointer.
+type jsonPatchTarget struct {
+	path []PathElement
+	// The type of the value at path.
+	valueType reflect.Type
+	// The kind of container holding the value, invalid for the root.
+	parentKind reflect.Kind
+	// True if the JSON value maps to a Go pointer field, path includes the
+	// pointer step and valueType is the type pointed to.
+	isPointer bool
+}
+
+func (p *jsonPatchParser) resolve(pointer string) (target jsonPatchTarget, err error) {
+	if strings.HasSuffix(pointer, "/-") {
+		// Append to the end of an array.
+		if !p.hasObject() {
+			return target, fmt.Errorf("appending with '-' requires an object")
+		}
+
+		parent, err := p.resolve(strings.TrimSuffix(pointer, "/-"))
+		if err != nil {
+			return target, err
+		}
+		slice, err := lookupPath(p.current, parent.path)
+		if err != nil {
+			return target, err
+		}
+		if slice.Kind() != reflect.Slice {
+			return target, fmt.Errorf("can not append to %v", slice.Type())
+		}
+		pointer = strings.TrimSuffix(pointer, "-") + fmt.Sprint(slice.Len())
+	}
+
+	path, valueType, err := resolveJSONPointer(p.cs.BaseType, pointer)
+	if err != nil {
+		return target, err
+	}
+
+	target = jsonPatchTarget{path: path, valueType: valueType, parentKind: reflect.Invalid}
+	if len(path) > 0 {
+		parentType, err := typeAtPath(p.cs.BaseType, path[:len(path)-1])
+		if err != nil {
+			return target, err
+		}
+		target.parentKind = parentType.Kind()
+	}
+
+	// Pointers held in maps and slices are kept as they are, the same as
+	// Diff would, so that removing them removes the element.
+	if valueType.Kind() == reflect.Ptr && target.parentKind != reflect.Map && target.parentKind != reflect.Slice {
+		target.path = extendContext(path, NewPtrElem())
+		target.valueType = valueType.Elem()
+		target.isPointer = true
+	}
+	return target, nil
+}
+
+// Returns the value at target and whether it exists. Without an object
+// every target is assumed to exist.
+func (p *jsonPatchParser) lookup(target jsonPatchTarget) (reflect.Value, bool) {
+	if !p.hasObject() {
+		return reflect.Value{}, true
+	}
+
+	value, err := lookupPath(p.current, target.path)
+	return value, err == nil
+}
+
+func decodeJSONValue(valueType reflect.Type, raw json.RawMessage) (reflect.Value, error) {
+	if raw == nil {
+		return reflect.Value{}, fmt.Errorf("missing value")
+	}
+
+	value := reflect.New(valueType)
+	if err := json.Unmarshal(raw, value.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return value.Elem(), nil
+}
+
+func (p *jsonPatchParser) parseAdd(pointer string, raw json.RawMessage) ([]Change, error) {
+	target, err := p.resolve(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.isPointer && string(raw) == "null" {
+		if old, exists := p.lookup(target); exists {
+			return []Change{NewValueDeletion(target.path, old)}, nil
+		}
+		return nil, nil
+	}
+
+	newValue, err := decodeJSONValue(target.valueType, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	old, exists := p.lookup(target)
+	switch target.parentKind {
+	case reflect.Slice:
+		if p.hasObject() {
+			slice, err := lookupPath(p.current, target.path[:len(target.path)-1])
+			if err != nil {
+				return nil, err
+			}
+			if index := target.path[len(target.path)-1].GetIndex(); index > slice.Len() {
+				return nil, fmt.Errorf("index %v out of range for %v elements", index, slice.Len())
+			}
+		}
+		return []Change{NewValueAddition(target.path, newValue)}, nil
+	case reflect.Map:
+		if !p.hasObject() || !exists {
+			return []Change{NewValueAddition(target.path, newValue)}, nil
+		}
+	}
+
+	if target.isPointer && !exists {
+		return []Change{NewValueAddition(target.path, newValue)}, nil
+	}
+	return []Change{NewValueChange(target.path, old, newValue)}, nil
+}
+
+func (p *jsonPatchParser) parseRemove(pointer string) ([]Change, error) {
+	target, err := p.resolve(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	old, exists := p.lookup(target)
+	if !exists {
+		return nil, fmt.Errorf("nothing to remove")
+	}
+
+	// Removing a struct field or interface resets it to its zero value, the
+	// same as a missing member when decoding. The elements of an array can
+	// not be removed without moving the rest.
+	switch target.parentKind {
+	case reflect.Invalid:
+		return nil, fmt.Errorf("can not remove the root value")
+	case reflect.Array:
+		return nil, fmt.Errorf("can not remove an element of an array")
+	}
+
+	return []Change{NewValueDeletion(target.path, old)}, nil
+}
+
+func (p *jsonPatchParser) parseReplace(pointer string, raw json.RawMessage) ([]Change, error) {
+	target, err := p.resolve(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	old, exists := p.lookup(target)
+	if !exists && !target.isPointer {
+		return nil, fmt.Errorf("nothing to replace")
+	}
+
+	if target.isPointer && string(raw) == "null" {
+		if !exists {
+			return nil, nil
+		}
+		return []Change{NewValueDeletion(target.path, old)}, nil
+	}
+
+	newValue, err := decodeJSONValue(target.valueType, raw)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return []Change{NewValueAddition(target.path, newValue)}, nil
+	}
+	return []Change{NewValueChange(target.path, old, newValue)}, nil
+}
+
+// Encodes the current value at pointer as JSON, a nil pointer is null.
+func (p *jsonPatchParser) currentJSON(pointer string) (json.RawMessage, error) {
+	if !p.hasObject() {
+		return nil, fmt.Errorf("requires an object")
+	}
+
+	path, _, err := resolveJSONPointer(p.cs.BaseType, pointer)
+	if err != nil {
+		return nil, err
+	}
+	value, err := lookupPath(p.current, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value.Interface())
+}
+
+func (p *jsonPatchParser) parseTest(pointer string, raw json.RawMessage) error {
+	actual, err := p.currentJSON(pointer)
+	if err != nil {
+		return err
+	}
+
+	var expectValue, actualValue interface{}
+	if err := json.Unmarshal(raw, &expectValue); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(actual, &actualValue); err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(expectValue, actualValue) {
+		path, _, _ := resolveJSONPointer(p.cs.BaseType, pointer)
+		return ConflictError{Path: path, ChangeIndex: -1, Reason: fmt.Sprintf("test failed, value is %s", actual)}
+	}
+	return nil
+}
+
+func (p *jsonPatchParser) parseCopy(from string, pointer string) ([]Change, error) {
+	value, err := p.currentJSON(from)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parseAdd(pointer, value)
+}
+
+func (p *jsonPatchParser) parseMove(from string, pointer string) ([]Change, error) {
+	if from == pointer {
+		return nil, nil
+	}
+	if strings.HasPrefix(pointer, from+"/") {
+		return nil, fmt.Errorf("can not move a value into itself")
+	}
+
+	value, err := p.currentJSON(from)
+	if err != nil {
+		return nil, err
+	}
+
+	// The removal is committed first so that moving within an array
+	// addresses the same indexes a JSON Patch implementation would.
+	removal, err := p.parseRemove(from)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.commit(removal); err != nil {
+		return nil, err
+	}
+
+	return p.parseAdd(pointer, value)
+}
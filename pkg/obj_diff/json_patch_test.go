@@ -0,0 +1,506 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type JSONTestMeta struct {
+	Name        string            `json:"name"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type jsonContainer struct {
+	Name  string  `json:"name"`
+	Image string  `json:"image"`
+	Limit *string `json:"limit,omitempty"`
+}
+
+type jsonSpec struct {
+	Replicas   *int32          `json:"replicas"`
+	Paused     *bool           `json:"paused,omitempty"`
+	Containers []jsonContainer `json:"containers"`
+	Ports      []int32         `json:"ports,omitempty"`
+	Selector   map[int]string  `json:"selector"`
+}
+
+type jsonObject struct {
+	JSONTestMeta `json:",inline"`
+	Spec         jsonSpec `json:"spec"`
+	Internal     string   `json:"-"`
+	Untagged     int
+}
+
+func buildJSONObjects() (jsonObject, jsonObject) {
+	three := int32(3)
+	five := int32(5)
+	paused := true
+	limit := "1Gi"
+	o1 := jsonObject{
+		JSONTestMeta: JSONTestMeta{Name: "a", Annotations: map[string]string{"a/b": "1", "c~d": "2"}},
+		Spec: jsonSpec{Replicas: &three, Paused: &paused,
+			Containers: []jsonContainer{{Name: "a", Image: "a:1"}, {Name: "b", Image: "b:1", Limit: &limit}},
+			Ports:      []int32{80, 443, 8080, 8443},
+			Selector:   map[int]string{1: "a"}},
+		Untagged: 1,
+	}
+	o2 := jsonObject{
+		JSONTestMeta: JSONTestMeta{Name: "b", Annotations: map[string]string{"a/b": "3", "e": "4"}},
+		Spec: jsonSpec{Replicas: &five,
+			Containers: []jsonContainer{{Name: "a", Image: "a:2"}, {Name: "b", Image: "b:1"}, {Name: "c", Image: "c:1"}},
+			Ports:      []int32{80},
+			Selector:   map[int]string{1: "b"}},
+		Untagged: 2,
+	}
+	return o1, o2
+}
+
+func TestJSONPatch(t *testing.T) {
+	o1, o2 := buildJSONObjects()
+	diff, err := Diff(&o1, &o2)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	actual, err := diff.MarshalJSONPatch()
+	if err != nil {
+		t.Fatalf("Error in MarshalJSONPatch: %v", err)
+	}
+
+	expect := []JSONPatchOperation{
+		{Op: "replace", Path: "/name", Value: json.RawMessage(`"b"`)},
+		{Op: "replace", Path: "/annotations/a~1b", Value: json.RawMessage(`"3"`)},
+		{Op: "remove", Path: "/annotations/c~0d"},
+		{Op: "add", Path: "/annotations/e", Value: json.RawMessage(`"4"`)},
+		{Op: "replace", Path: "/spec/replicas", Value: json.RawMessage(`5`)},
+		{Op: "remove", Path: "/spec/paused"},
+		{Op: "replace", Path: "/spec/containers/0/image", Value: json.RawMessage(`"a:2"`)},
+		{Op: "remove", Path: "/spec/containers/1/limit"},
+		{Op: "add", Path: "/spec/containers/2", Value: json.RawMessage(`{"name":"c","image":"c:1"}`)},
+		{Op: "remove", Path: "/spec/ports/3"},
+		{Op: "remove", Path: "/spec/ports/2"},
+		{Op: "remove", Path: "/spec/ports/1"},
+		{Op: "replace", Path: "/spec/selector/1", Value: json.RawMessage(`"b"`)},
+		{Op: "replace", Path: "/Untagged", Value: json.RawMessage(`2`)},
+	}
+	expectBytes, err := json.Marshal(expect)
+	if err != nil {
+		t.Fatalf("Error in Marshal: %v", err)
+	}
+
+	if string(expectBytes) != string(actual) {
+		t.Logf("Expect: %s", expectBytes)
+		t.Logf("Actual: %s", actual)
+		t.Fail()
+	}
+}
+
+func TestJSONPatchNull(t *testing.T) {
+	five := int32(5)
+	diff, err := Diff(jsonSpec{Replicas: &five}, jsonSpec{})
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	ops, err := diff.JSONPatch()
+	if err != nil {
+		t.Fatalf("Error in JSONPatch: %v", err)
+	}
+
+	expect := []JSONPatchOperation{{Op: "replace", Path: "/replicas", Value: json.RawMessage("null")}}
+	if !reflect.DeepEqual(expect, ops) {
+		t.Logf("Expect: %+v", expect)
+		t.Logf("Actual: %+v", ops)
+		t.Fail()
+	}
+}
+
+func TestJSONPatchUnencodable(t *testing.T) {
+	diff, err := Diff(jsonObject{Internal: "a"}, jsonObject{Internal: "b"})
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	if _, err := diff.JSONPatch(); err == nil {
+		t.Errorf("Expected an error for a field not encoded to JSON")
+	}
+
+	bytes, err := Diff(map[string][]byte{"a": []byte("abc")}, map[string][]byte{"a": []byte("abd")})
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	if _, err := bytes.JSONPatch(); err == nil {
+		t.Errorf("Expected an error for an index into []byte")
+	}
+}
+
+func TestParseJSONPatch(t *testing.T) {
+	o1, o2 := buildJSONObjects()
+	diff, err := Diff(o1, o2)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	patch, err := diff.MarshalJSONPatch()
+	if err != nil {
+		t.Fatalf("Error in MarshalJSONPatch: %v", err)
+	}
+
+	parsed, err := ParseJSONPatch(patch, reflect.TypeOf(o1))
+	if err != nil {
+		t.Fatalf("Error in ParseJSONPatch: %v", err)
+	}
+
+	actual, err := parsed.Apply(o1)
+	if err != nil {
+		t.Fatalf("Error in Apply: %v", err)
+	}
+	if !reflect.DeepEqual(o2, actual) {
+		t.Logf("Patch: %s", patch)
+		t.Logf("Expect: %+v", o2)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+
+	parsedFor, err := ParseJSONPatchFor(patch, o1)
+	if err != nil {
+		t.Fatalf("Error in ParseJSONPatchFor: %v", err)
+	}
+	if actual, err := parsedFor.Apply(o1); err != nil || !reflect.DeepEqual(o2, actual) {
+		t.Logf("Expect: %+v", o2)
+		t.Logf("Actual: %+v", actual)
+		t.Fatalf("Unexpected result from ParseJSONPatchFor (%v)", err)
+	}
+}
+
+func TestParseJSONPatchOperations(t *testing.T) {
+	o1, _ := buildJSONObjects()
+	original := CopyValueReflectively(o1)
+	patch := []byte(`[
+		{"op": "test", "path": "/spec/containers/1/image", "value": "b:1"},
+		{"op": "copy", "from": "/spec/containers/0", "path": "/spec/containers/-"},
+		{"op": "replace", "path": "/spec/containers/2/name", "value": "c"},
+		{"op": "move", "from": "/annotations/a~1b", "path": "/annotations/moved"},
+		{"op": "add", "path": "/spec/paused", "value": null},
+		{"op": "test", "path": "/spec/paused", "value": null},
+		{"op": "add", "path": "/spec/selector/2", "value": "b"},
+		{"op": "remove", "path": "/spec/ports/3"},
+		{"op": "remove", "path": "/spec/ports/0"},
+		{"op": "add", "path": "/spec/ports/1", "value": 9}
+	]`)
+
+	cs, err := ParseJSONPatchFor(patch, o1)
+	if err != nil {
+		t.Fatalf("Error in ParseJSONPatchFor: %v", err)
+	}
+	if !reflect.DeepEqual(original, o1) {
+		t.Fatalf("ParseJSONPatchFor modified the object")
+	}
+
+	actual, err := cs.Apply(o1)
+	if err != nil {
+		t.Fatalf("Error in Apply: %v", err)
+	}
+
+	expect := CopyValueReflectively(o1).(jsonObject)
+	expect.Spec.Containers = append(expect.Spec.Containers, jsonContainer{Name: "c", Image: "a:1"})
+	expect.Annotations = map[string]string{"moved": "1", "c~d": "2"}
+	expect.Spec.Paused = nil
+	expect.Spec.Selector[2] = "b"
+	expect.Spec.Ports = []int32{443, 9, 8080}
+	if !reflect.DeepEqual(expect, actual) {
+		t.Logf("Changes: %v", cs.Changes)
+		t.Logf("Expect: %+v", expect)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+
+	failing := []string{
+		`[{"op": "test", "path": "/name", "value": "b"}]`,
+		`[{"op": "remove", "path": "/annotations/missing"}]`,
+		`[{"op": "replace", "path": "/spec/missing", "value": 1}]`,
+		`[{"op": "remove", "path": ""}]`,
+		`[{"op": "move", "from": "/spec", "path": "/spec/containers"}]`,
+		`[{"op": "unknown", "path": "/name"}]`,
+		`[{"op": "add", "path": "/spec/ports/5", "value": 1}]`,
+	}
+	for _, patch := range failing {
+		if _, err := ParseJSONPatchFor([]byte(patch), o1); err == nil {
+			t.Errorf("Expected an error for %v", patch)
+		}
+	}
+
+	if _, err := ParseJSONPatch([]byte(`[{"op": "test", "path": "/name", "value": "a"}]`), reflect.TypeOf(o1)); err == nil {
+		t.Errorf("Expected an error for test without an object")
+	}
+}
+
+type jsonRemovable struct {
+	Name    string      `json:"name"`
+	Count   int         `json:"count,omitempty"`
+	Value   interface{} `json:"value"`
+	Digests [2]string   `json:"digests"`
+}
+
+func TestParseJSONPatchRemove(t *testing.T) {
+	base := jsonRemovable{Name: "a", Count: 3, Value: "x", Digests: [2]string{"b", "c"}}
+	update := jsonRemovable{Name: "a", Value: "x", Digests: [2]string{"b", "c"}}
+
+	// Round trip a change to an omitted scalar.
+	diff, err := Diff(base, update)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+	patch, err := diff.MarshalJSONPatch()
+	if err != nil {
+		t.Fatalf("Error in MarshalJSONPatch: %v", err)
+	}
+	if string(patch) != `[{"op":"remove","path":"/count"}]` {
+		t.Errorf("Unexpected patch %s", patch)
+	}
+	parsed, err := ParseJSONPatch(patch, reflect.TypeOf(base))
+	if err != nil {
+		t.Fatalf("Error in ParseJSONPatch: %v", err)
+	}
+	actual := base
+	if err := parsed.Patch(&actual); err != nil {
+		t.Fatalf("Error in Patch: %v", err)
+	}
+	if !reflect.DeepEqual(update, actual) {
+		t.Logf("Expect: %+v", update)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+
+	// Removing a field or interface resets it to its zero value.
+	patch = []byte(`[{"op": "remove", "path": "/name"}, {"op": "remove", "path": "/value"}]`)
+	for _, obj := range []interface{}{nil, base} {
+		var parsed *ChangeSet
+		if obj == nil {
+			parsed, err = ParseJSONPatch(patch, reflect.TypeOf(base))
+		} else {
+			parsed, err = ParseJSONPatchFor(patch, obj)
+		}
+		if err != nil {
+			t.Fatalf("Error in ParseJSONPatch: %v", err)
+		}
+		actual := base
+		if err := parsed.Patch(&actual); err != nil {
+			t.Fatalf("Error in Patch: %v", err)
+		}
+		expect := jsonRemovable{Count: 3, Digests: [2]string{"b", "c"}}
+		if !reflect.DeepEqual(expect, actual) {
+			t.Logf("Expect: %+v", expect)
+			t.Logf("Actual: %+v", actual)
+			t.Fail()
+		}
+	}
+
+	if _, err := ParseJSONPatchFor([]byte(`[{"op": "remove", "path": "/digests/0"}]`), base); err == nil {
+		t.Errorf("Expected an error removing an array element")
+	}
+}
+
+type jsonContainers struct {
+	Map      map[string]int `json:"m"`
+	List     []int          `json:"l"`
+	Optional map[string]int `json:"o,omitempty"`
+	Items    []int          `json:"i,omitempty"`
+	Name     string         `json:"n,omitempty"`
+	Nested   []jsonSpec     `json:"nested"`
+}
+
+func TestJSONPatchApplies(t *testing.T) {
+	one := int32(1)
+	full := jsonContainers{Map: map[string]int{"a": 1}, List: []int{1}, Optional: map[string]int{"b": 2}, Items: []int{1, 2},
+		Name: "x", Nested: []jsonSpec{{Replicas: &one, Ports: []int32{80}, Selector: map[int]string{1: "a"}}}}
+	partial := jsonContainers{Map: map[string]int{}, Optional: map[string]int{"c": 3},
+		Nested: []jsonSpec{{}}}
+	empty := jsonContainers{Map: map[string]int{}, List: []int{}, Optional: map[string]int{}, Items: []int{},
+		Nested: []jsonSpec{}}
+
+	tests := []struct {
+		name   string
+		base   jsonContainers
+		update jsonContainers
+	}{
+		{name: "Create", base: jsonContainers{}, update: full},
+		{name: "Remove", base: full, update: jsonContainers{}},
+		{name: "Partial", base: partial, update: full},
+		{name: "Partial Remove", base: full, update: partial},
+		{name: "From Empty", base: empty, update: full},
+		{name: "To Empty", base: full, update: empty},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff, err := Diff(test.base, test.update)
+			if err != nil {
+				t.Fatalf("Error in Diff: %v", err)
+			}
+			ops, err := diff.JSONPatchFor(test.base)
+			if err != nil {
+				t.Fatalf("Error in JSONPatchFor: %v", err)
+			}
+			patched, err := diff.Apply(test.base)
+			if err != nil {
+				t.Fatalf("Error in Apply: %v", err)
+			}
+
+			// The patch must have the same result as Patch, which leaves
+			// emptied maps and slices in place rather than nil.
+			var doc, expect interface{}
+			if err := roundTripJSON(test.base, &doc); err != nil {
+				t.Fatalf("Error encoding base: %v", err)
+			}
+			if err := roundTripJSON(patched, &expect); err != nil {
+				t.Fatalf("Error encoding update: %v", err)
+			}
+
+			actual, err := applyJSONPatchOperations(doc, ops)
+			if err != nil {
+				t.Fatalf("Error applying %+v: %v", ops, err)
+			}
+			if !reflect.DeepEqual(expect, actual) {
+				t.Logf("Ops: %+v", ops)
+				t.Logf("Expect: %+v", expect)
+				t.Logf("Actual: %+v", actual)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestJSONPatchForMissingContainers(t *testing.T) {
+	base := jsonContainers{Optional: map[string]int{}, Items: []int{}, List: []int{1}}
+	update := jsonContainers{Optional: map[string]int{"a": 1, "b": 2}, Items: []int{3}, Map: map[string]int{"c": 4}}
+	diff, err := Diff(base, update)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	actual, err := diff.MarshalJSONPatchFor(base)
+	if err != nil {
+		t.Fatalf("Error in MarshalJSONPatchFor: %v", err)
+	}
+	expect := `[{"op":"add","path":"/m","value":{"c":4}},{"op":"remove","path":"/l/0"},` +
+		`{"op":"add","path":"/o","value":{"a":1,"b":2}},{"op":"add","path":"/i","value":[3]}]`
+	if string(actual) != expect {
+		t.Logf("Expect: %v", expect)
+		t.Logf("Actual: %s", actual)
+		t.Fail()
+	}
+
+	removed, err := Diff(update, jsonContainers{Optional: map[string]int{}, Map: map[string]int{"c": 4}})
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+	actual, err = removed.MarshalJSONPatchFor(update)
+	if err != nil {
+		t.Fatalf("Error in MarshalJSONPatchFor: %v", err)
+	}
+	expect = `[{"op":"remove","path":"/o/a"},{"op":"remove","path":"/o"},{"op":"remove","path":"/i"}]`
+	if string(actual) != expect {
+		t.Logf("Expect: %v", expect)
+		t.Logf("Actual: %s", actual)
+		t.Fail()
+	}
+
+	if _, err := diff.JSONPatchFor(nil); err == nil {
+		t.Errorf("Expected an error for nil")
+	}
+}
+
+func roundTripJSON(value interface{}, decoded interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, decoded)
+}
+
+// A strict RFC 6902 add, remove and replace against a decoded JSON document,
+// the parent of every target must exist.
+func applyJSONPatchOperations(doc interface{}, ops []JSONPatchOperation) (interface{}, error) {
+	for _, op := range ops {
+		var value interface{}
+		if op.Op != JSONPatchRemove {
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return nil, err
+			}
+		}
+
+		tokens := strings.Split(op.Path, "/")[1:]
+		if len(tokens) == 0 {
+			doc = value
+			continue
+		}
+		for i := range tokens {
+			tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(tokens[i])
+		}
+
+		updated, err := applyJSONPatchAt(doc, tokens, op.Op, value)
+		if err != nil {
+			return nil, fmt.Errorf("%v %v: %v", op.Op, op.Path, err)
+		}
+		doc = updated
+	}
+	return doc, nil
+}
+
+// Applies op to the member of parent at tokens and returns the updated parent.
+func applyJSONPatchAt(parent interface{}, tokens []string, op string, value interface{}) (interface{}, error) {
+	switch parent := parent.(type) {
+	case map[string]interface{}:
+		child, exists := parent[tokens[0]]
+		if len(tokens) > 1 {
+			if !exists {
+				return nil, fmt.Errorf("missing parent %q", tokens[0])
+			}
+			updated, err := applyJSONPatchAt(child, tokens[1:], op, value)
+			parent[tokens[0]] = updated
+			return parent, err
+		}
+
+		if op != JSONPatchAdd && !exists {
+			return nil, fmt.Errorf("missing target %q", tokens[0])
+		}
+		if op == JSONPatchRemove {
+			delete(parent, tokens[0])
+		} else {
+			parent[tokens[0]] = value
+		}
+		return parent, nil
+
+	case []interface{}:
+		index, err := strconv.Atoi(tokens[0])
+		if err != nil || index < 0 || index > len(parent) || (index == len(parent) && (len(tokens) > 1 || op != JSONPatchAdd)) {
+			return nil, fmt.Errorf("invalid index %q", tokens[0])
+		}
+		if len(tokens) > 1 {
+			updated, err := applyJSONPatchAt(parent[index], tokens[1:], op, value)
+			parent[index] = updated
+			return parent, err
+		}
+
+		switch op {
+		case JSONPatchAdd:
+			parent = append(parent[:index], append([]interface{}{value}, parent[index:]...)...)
+		case JSONPatchRemove:
+			parent = append(parent[:index], parent[index+1:]...)
+		default:
+			parent[index] = value
+		}
+		return parent, nil
+	}
+
+	return nil, fmt.Errorf("parent of %q is not an object or array", tokens[0])
+}
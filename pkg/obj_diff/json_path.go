@@ -0,0 +1,396 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"encoding"
+	"fmt"
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A single step in the JSON encoding of an object.
+type JSONSegment struct {
+	// The member name, or the decimal index for arrays.
+	Name string
+	// True if this segment is an array index.
+	IsIndex bool
+	// True if this segment is a struct field tagged with omitempty.
+	OmitEmpty bool
+}
+
+// Translates a path through baseType into the steps through its JSON
+// encoding as produced by encoding/json. Struct fields use their json tag
+// names, embedded structs are inlined and pointers are transparent.
+func JSONSegments(baseType reflect.Type, path []PathElement) ([]JSONSegment, error) {
+	if baseType == nil {
+		return nil, fmt.Errorf("no type to follow %v through", pathString(path))
+	}
+
+	segments := []JSONSegment{}
+	currType := baseType
+	for i, pe := range path {
+		switch currType.Kind() {
+		case reflect.Struct:
+			index, err := fieldIndex(currType, pe)
+			if err != nil {
+				return nil, fmt.Errorf("%v at %v", err, pathString(path[:i+1]))
+			}
+			field := currType.Field(index)
+			name, omitEmpty, inline, err := jsonFieldName(field)
+			if err != nil {
+				return nil, fmt.Errorf("%v at %v", err, pathString(path[:i+1]))
+			}
+			if !inline {
+				segments = append(segments, JSONSegment{Name: name, OmitEmpty: omitEmpty})
+			}
+			currType = field.Type
+
+		case reflect.Map:
+			name, err := jsonMapKey(pe.GetKey())
+			if err != nil {
+				return nil, fmt.Errorf("%v at %v", err, pathString(path[:i+1]))
+			}
+			segments = append(segments, JSONSegment{Name: name})
+			currType = currType.Elem()
+
+		case reflect.Array, reflect.Slice:
+			if currType.Elem().Kind() == reflect.Uint8 {
+				return nil, fmt.Errorf("can not address into %v, it is encoded as a string at %v", currType, pathString(path[:i+1]))
+			}
+			segments = append(segments, JSONSegment{Name: strconv.Itoa(pe.GetIndex()), IsIndex: true})
+			currType = currType.Elem()
+
+		case reflect.Ptr:
+			currType = currType.Elem()
+
+		default:
+			return nil, fmt.Errorf("can not traverse %v at %v", currType, pathString(path[:i+1]))
+		}
+	}
+
+	return segments, nil
+}
+
+// Returns the name encoding/json uses for field, whether it is omitempty and
+// whether its members are inlined into the parent.
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty bool, inline bool, err error) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false, fmt.Errorf("field %v is not encoded to JSON", field.Name)
+	}
+
+	options := strings.Split(tag, ",")
+	name = options[0]
+	for _, option := range options[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if field.Anonymous && len(name) == 0 && fieldType.Kind() == reflect.Struct {
+		return "", omitEmpty, true, nil
+	}
+
+	if len(field.PkgPath) > 0 {
+		return "", false, false, fmt.Errorf("field %v is unexported", field.Name)
+	}
+
+	if len(name) == 0 {
+		name = field.Name
+	}
+	return name, omitEmpty, false, nil
+}
+
+// Formats a map key as encoding/json would when it is used as a member name.
+func jsonMapKey(key reflect.Value) (string, error) {
+	if !key.IsValid() {
+		return "", fmt.Errorf("missing map key")
+	}
+
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+
+	if marshaler, ok := key.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		return string(text), err
+	}
+
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+
+	return "", fmt.Errorf("map key of type %v can not be encoded to JSON", key.Type())
+}
+
+// Parses a member name into a map key of keyType, the inverse of jsonMapKey.
+func parseJSONMapKey(keyType reflect.Type, name string) (reflect.Value, error) {
+	key := reflect.New(keyType)
+	if unmarshaler, ok := key.Interface().(encoding.TextUnmarshaler); ok && keyType.Kind() != reflect.String {
+		err := unmarshaler.UnmarshalText([]byte(name))
+		return key.Elem(), err
+	}
+
+	var err error
+	switch keyType.Kind() {
+	case reflect.String:
+		key.Elem().SetString(name)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(name, 10, keyType.Bits()); err == nil {
+			key.Elem().SetInt(i)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u uint64
+		if u, err = strconv.ParseUint(name, 10, keyType.Bits()); err == nil {
+			key.Elem().SetUint(u)
+		}
+	default:
+		err = fmt.Errorf("map key of type %v can not be decoded from JSON", keyType)
+	}
+
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid key %q for %v: %v", name, keyType, err)
+	}
+	return key.Elem(), nil
+}
+
+// Renders path through baseType as an RFC 6901 JSON Pointer using the json
+// tag names, for example /spec/containers/0/image.
+func FormatJSONPointer(baseType reflect.Type, path []PathElement) (string, error) {
+	segments, err := JSONSegments(baseType, path)
+	if err != nil {
+		return "", err
+	}
+	return jsonPointer(segments), nil
+}
+
+// Renders path through baseType as a JSONPath expression using the json tag
+// names, for example $.spec.containers[0].image. Member names that are not
+// identifiers are quoted, as in $.metadata.labels['app.kubernetes.io/name'].
+func FormatJSONPath(baseType reflect.Type, path []PathElement) (string, error) {
+	segments, err := JSONSegments(baseType, path)
+	if err != nil {
+		return "", err
+	}
+
+	jsonPath := "$"
+	for _, seg := range segments {
+		if seg.IsIndex {
+			jsonPath += "[" + seg.Name + "]"
+		} else if isJSONPathIdent(seg.Name) {
+			jsonPath += "." + seg.Name
+		} else {
+			jsonPath += "['" + jsonPathEscaper.Replace(seg.Name) + "']"
+		}
+	}
+	return jsonPath, nil
+}
+
+var jsonPathEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+func isJSONPathIdent(name string) bool {
+	if len(name) == 0 || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isIdentChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Renders segments as an RFC 6901 JSON Pointer.
+func jsonPointer(segments []JSONSegment) string {
+	pointer := ""
+	for _, seg := range segments {
+		pointer += "/" + escapeJSONPointer(seg.Name)
+	}
+	return pointer
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// Escapes a single JSON Pointer reference token.
+func escapeJSONPointer(token string) string {
+	return jsonPointerEscaper.Replace(token)
+}
+
+// Resolves an RFC 6901 JSON Pointer against baseType, returning the path
+// through the Go type. This is the inverse of FormatJSONPointer.
+func ResolveJSONPointer(baseType reflect.Type, pointer string) ([]PathElement, error) {
+	path, _, err := resolveJSONPointer(baseType, pointer)
+	return path, err
+}
+
+// Resolves a JSONPath expression, as produced by FormatJSONPath, against
+// baseType. Only the child operators .name, ['name'] and [index] are
+// supported.
+func ResolveJSONPath(baseType reflect.Type, jsonPath string) ([]PathElement, error) {
+	tokens, err := parseJSONPath(jsonPath)
+	if err != nil {
+		return nil, err
+	}
+
+	pointer := ""
+	for _, token := range tokens {
+		pointer += "/" + escapeJSONPointer(token)
+	}
+	return ResolveJSONPointer(baseType, pointer)
+}
+
+// Splits a JSONPath expression into its member names and indexes.
+func parseJSONPath(jsonPath string) ([]string, error) {
+	if !strings.HasPrefix(jsonPath, "$") {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with '$'", jsonPath)
+	}
+
+	tokens := []string{}
+	for pos := 1; pos < len(jsonPath); {
+		switch {
+		case jsonPath[pos] == '.':
+			end := pos + 1
+			for end < len(jsonPath) && isIdentChar(jsonPath[end]) {
+				end++
+			}
+			if end == pos+1 {
+				return nil, fmt.Errorf("invalid JSONPath %q: missing name at %v", jsonPath, pos)
+			}
+			tokens = append(tokens, jsonPath[pos+1:end])
+			pos = end
+
+		case strings.HasPrefix(jsonPath[pos:], "['"):
+			token := ""
+			end := pos + 2
+			for ; end < len(jsonPath) && jsonPath[end] != '\''; end++ {
+				if jsonPath[end] == '\\' && end+1 < len(jsonPath) {
+					end++
+				}
+				token += string(jsonPath[end])
+			}
+			if !strings.HasPrefix(jsonPath[end:], "']") {
+				return nil, fmt.Errorf("invalid JSONPath %q: unterminated name at %v", jsonPath, pos)
+			}
+			tokens = append(tokens, token)
+			pos = end + 2
+
+		case jsonPath[pos] == '[':
+			closing := strings.IndexByte(jsonPath[pos:], ']')
+			if closing < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unterminated index at %v", jsonPath, pos)
+			}
+			tokens = append(tokens, jsonPath[pos+1:pos+closing])
+			pos += closing + 1
+
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected '%c' at %v", jsonPath, jsonPath[pos], pos)
+		}
+	}
+
+	return tokens, nil
+}
+
+// Resolves an RFC 6901 JSON Pointer against baseType, returning the path
+// through the Go type and the type found at the end of it. Pointers in the
+// Go type are followed, adding a pointer step to the path unless they are
+// the final value.
+func resolveJSONPointer(baseType reflect.Type, pointer string) ([]PathElement, reflect.Type, error) {
+	if baseType == nil {
+		return nil, nil, fmt.Errorf("no type to resolve %q against", pointer)
+	}
+	if len(pointer) == 0 {
+		return []PathElement{}, baseType, nil
+	}
+	if pointer[0] != '/' {
+		return nil, nil, fmt.Errorf("invalid JSON Pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	path := []PathElement{}
+	currType := baseType
+	for _, token := range tokens {
+		token = jsonPointerUnescaper.Replace(token)
+		for currType.Kind() == reflect.Ptr {
+			path = append(path, NewPtrElem())
+			currType = currType.Elem()
+		}
+
+		var err error
+		path, currType, err = resolveJSONToken(path, currType, token)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can not resolve %q in %v: %v", pointer, baseType, err)
+		}
+	}
+
+	return path, currType, nil
+}
+
+var jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// Resolves a single JSON Pointer reference token against currType.
+func resolveJSONToken(path []PathElement, currType reflect.Type, token string) ([]PathElement, reflect.Type, error) {
+	switch currType.Kind() {
+	case reflect.Struct:
+		return resolveJSONField(path, currType, token)
+
+	case reflect.Map:
+		key, err := parseJSONMapKey(currType.Key(), token)
+		if err != nil {
+			return nil, nil, err
+		}
+		return append(path, NewKeyElem(key)), currType.Elem(), nil
+
+	case reflect.Array, reflect.Slice:
+		if currType.Elem().Kind() == reflect.Uint8 {
+			return nil, nil, fmt.Errorf("can not address into %v, it is encoded as a string", currType)
+		}
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || (token != "0" && token[0] == '0') {
+			return nil, nil, fmt.Errorf("invalid index %q", token)
+		}
+		return append(path, NewIndexElem(index)), currType.Elem(), nil
+	}
+
+	return nil, nil, fmt.Errorf("can not traverse %v", currType)
+}
+
+// Finds the struct field encoded as name, searching the fields of embedded
+// structs which encoding/json inlines.
+func resolveJSONField(path []PathElement, structType reflect.Type, name string) ([]PathElement, reflect.Type, error) {
+	for f := 0; f < structType.NumField(); f++ {
+		field := structType.Field(f)
+		fieldName, _, inline, err := jsonFieldName(field)
+		if err != nil {
+			continue
+		}
+
+		fieldPath := extendContext(path, NewFieldElem(f, field.Name))
+		if inline {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldPath = extendContext(fieldPath, NewPtrElem())
+				fieldType = fieldType.Elem()
+			}
+			if found, foundType, err := resolveJSONField(fieldPath, fieldType, name); err == nil {
+				return found, foundType, nil
+			}
+		} else if fieldName == name {
+			return fieldPath, field.Type, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("no field encoded as %q in %v", name, structType)
+}
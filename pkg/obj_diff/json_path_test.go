@@ -0,0 +1,96 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+	"testing"
+)
+
+func TestFormatJSONPath(t *testing.T) {
+	objType := reflect.TypeOf(jsonObject{})
+	meta := NewFieldElem(0, "JSONTestMeta")
+	spec := NewFieldElem(1, "Spec")
+	tests := []struct {
+		path     []PathElement
+		pointer  string
+		jsonPath string
+		// The path resolved from pointer, if it differs from path.
+		resolved []PathElement
+	}{
+		{
+			path:     []PathElement{meta, NewFieldElem(0, "Name")},
+			pointer:  "/name",
+			jsonPath: "$.name",
+		},
+		{
+			path:     []PathElement{meta, NewFieldElem(1, "Annotations"), NewKeyElem("a/b")},
+			pointer:  "/annotations/a~1b",
+			jsonPath: "$.annotations['a/b']",
+		},
+		{
+			path:     []PathElement{meta, NewFieldElem(1, "Annotations"), NewKeyElem(`it's\`)},
+			pointer:  `/annotations/it's\`,
+			jsonPath: `$.annotations['it\'s\\']`,
+		},
+		{
+			path:     []PathElement{spec, NewFieldElem(2, "Containers"), NewIndexElem(1), NewFieldElem(1, "Image")},
+			pointer:  "/spec/containers/1/image",
+			jsonPath: "$.spec.containers[1].image",
+		},
+		{
+			path:     []PathElement{spec, NewFieldElem(0, "Replicas"), NewPtrElem()},
+			pointer:  "/spec/replicas",
+			jsonPath: "$.spec.replicas",
+			resolved: []PathElement{spec, NewFieldElem(0, "Replicas")},
+		},
+		{
+			path:     []PathElement{spec, NewFieldElem(4, "Selector"), NewKeyElem(1)},
+			pointer:  "/spec/selector/1",
+			jsonPath: "$.spec.selector['1']",
+		},
+		{
+			path:     []PathElement{},
+			pointer:  "",
+			jsonPath: "$",
+		},
+	}
+	for _, test := range tests {
+		pointer, err := FormatJSONPointer(objType, test.path)
+		if err != nil || pointer != test.pointer {
+			t.Logf("Expect: %v", test.pointer)
+			t.Logf("Actual: %v, %v", pointer, err)
+			t.Fail()
+		}
+
+		jsonPath, err := FormatJSONPath(objType, test.path)
+		if err != nil || jsonPath != test.jsonPath {
+			t.Logf("Expect: %v", test.jsonPath)
+			t.Logf("Actual: %v, %v", jsonPath, err)
+			t.Fail()
+		}
+
+		resolved := test.resolved
+		if resolved == nil {
+			resolved = test.path
+		}
+		fromPointer, err := ResolveJSONPointer(objType, test.pointer)
+		if err != nil || !pathsEqual(fromPointer, resolved) {
+			t.Errorf("Expected %v to resolve to %v, got: %v, %v", test.pointer, pathString(resolved), pathString(fromPointer), err)
+		}
+		fromJSONPath, err := ResolveJSONPath(objType, test.jsonPath)
+		if err != nil || !pathsEqual(fromJSONPath, resolved) {
+			t.Errorf("Expected %v to resolve to %v, got: %v, %v", test.jsonPath, pathString(resolved), pathString(fromJSONPath), err)
+		}
+	}
+
+	if _, err := FormatJSONPointer(objType, []PathElement{NewFieldElem(2, "Internal")}); err == nil {
+		t.Errorf("Expected an error formatting a field that is not encoded")
+	}
+	if _, err := ResolveJSONPath(objType, "spec.replicas"); err == nil {
+		t.Errorf("Expected an error resolving a JSONPath without '$'")
+	}
+}
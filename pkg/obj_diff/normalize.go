@@ -0,0 +1,156 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+	"sort"
+)
+
+// Returns a new ChangeSet which patches to the same result as this one with
+// redundant Changes removed. Repeated writes to the same path are collapsed
+// into one, an addition followed by a deletion is dropped, changes made
+// before their parent is replaced are dropped, and changes made after their
+// parent is replaced are folded into the parent's new value. The result is
+// ordered so that it is safe to Patch: slice deletions happen from the tail,
+// additions from the head, and parents come before children.
+//
+// As with the output of Diff, slice additions and deletions are assumed to
+// be at the tail of the slice. Inserting or removing in the middle of a slice
+// shifts the elements after it, so such ChangeSets depend on their order and
+// should not be normalized.
+func (cs ChangeSet) Normalize() (ChangeSet, error) {
+	normalized := []Change{}
+	for _, change := range cs.Changes {
+		var err error
+		normalized, err = cs.mergeChange(normalized, change)
+		if err != nil {
+			return ChangeSet{}, err
+		}
+	}
+
+	sort.SliceStable(normalized, func(i, j int) bool {
+		return compareForPatch(normalized[i], normalized[j]) < 0
+	})
+
+	return ChangeSet{BaseType: cs.BaseType, Changes: normalized}, nil
+}
+
+// Merges change into the already normalized changes. No change in the
+// normalized list is ever at or below another, this is maintained by
+// removing changes that are overwritten and folding in changes to children.
+func (cs ChangeSet) mergeChange(normalized []Change, change Change) ([]Change, error) {
+	path := change.GetPath()
+	merged := []Change{}
+	var previous Change
+	for _, existing := range normalized {
+		existingPath := existing.GetPath()
+		if pathsEqual(existingPath, path) {
+			previous = existing
+		} else if !hasPathPrefix(existingPath, path) {
+			// Anything below this change is overwritten by it.
+			merged = append(merged, existing)
+		}
+	}
+
+	if previous != nil {
+		if previous.IsAddition() {
+			if change.IsDeletion() {
+				// The value never existed to begin with.
+				return merged, nil
+			}
+			change = NewValueAddition(path, change.GetNewValue())
+		} else if change.IsDeletion() {
+			change = NewValueDeletion(path, previous.GetOldValue())
+		} else {
+			change = NewValueChange(path, previous.GetOldValue(), change.GetNewValue())
+		}
+
+		return append(merged, change), nil
+	}
+
+	for i, existing := range merged {
+		if !hasPathPrefix(path, existing.GetPath()) {
+			continue
+		}
+
+		folded, err := cs.foldChange(existing, change)
+		if err != nil {
+			return nil, err
+		}
+		merged[i] = folded
+		return merged, nil
+	}
+
+	return append(merged, change), nil
+}
+
+// Applies child to a copy of the value set by parent, returning a single
+// Change with the same outcome as both.
+func (cs ChangeSet) foldChange(parent Change, child Change) (Change, error) {
+	parentPath := parent.GetPath()
+	parentType, err := typeAtPath(cs.BaseType, parentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	value := reflect.New(parentType).Elem()
+	if parent.IsDeletion() {
+		// Patching a child of a deleted value recreates it.
+		value.Set(buildNewValue(parentType))
+	} else {
+		value.Set(CopyReflectValue(parent.GetNewValue()))
+	}
+
+	rebased := ChangeSet{
+		BaseType: parentType,
+		Changes:  []Change{withPath(child, child.GetPath()[len(parentPath):])},
+	}
+	if err := rebased.applyChanges(value); err != nil {
+		return nil, err
+	}
+
+	if parent.IsAddition() {
+		return NewValueAddition(parentPath, value), nil
+	}
+	return NewValueChange(parentPath, parent.GetOldValue(), value), nil
+}
+
+// Orders two changes so they can be safely patched. At the first point
+// their paths diverge, slice deletions come first from the highest index,
+// then everything else in ascending order.
+func compareForPatch(c1 Change, c2 Change) int {
+	p1 := c1.GetPath()
+	p2 := c2.GetPath()
+	for i := 0; i < len(p1) && i < len(p2); i++ {
+		pe1 := p1[i]
+		pe2 := p2[i]
+		if pe1.Equals(pe2) {
+			continue
+		}
+
+		k1 := pe1.GetKey()
+		k2 := pe2.GetKey()
+		if k1.IsValid() && k2.IsValid() {
+			return compareMapKeys(k1, k2)
+		}
+
+		isIndex := len(pe1.GetName()) == 0 && len(pe2.GetName()) == 0 && !k1.IsValid() && !k2.IsValid()
+		if isIndex {
+			deletion1 := c1.IsDeletion() && i == len(p1)-1
+			deletion2 := c2.IsDeletion() && i == len(p2)-1
+			if deletion1 && deletion2 {
+				return compareOrdered(pe1.GetIndex() > pe2.GetIndex(), pe1.GetIndex() < pe2.GetIndex())
+			} else if deletion1 != deletion2 {
+				return compareOrdered(deletion1, deletion2)
+			}
+		}
+
+		return compareOrdered(pe1.GetIndex() < pe2.GetIndex(), pe1.GetIndex() > pe2.GetIndex())
+	}
+
+	return compareOrdered(len(p1) < len(p2), len(p1) > len(p2))
+}
@@ -0,0 +1,109 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	nest1 := NestObj{9, "A"}
+	nest2 := NestObj{8, "B"}
+	o1 := Obj{Int: 1, Str: "Foo", IntList: []int64{1, 2, 3},
+		StrIntMap:  map[string]int64{"a": 1, "b": 2},
+		NestedPtr1: &nest1,
+		MapOfMaps:  map[string]map[string]NestObj{"a": {"b": nest1}}}
+	o2 := Obj{Int: 2, Str: "Bar", IntList: []int64{1},
+		StrIntMap:  map[string]int64{"a": 3, "c": 4},
+		NestedPtr1: &nest2, NestedPtr2: &nest1,
+		MapOfMaps: map[string]map[string]NestObj{"a": {"b": nest2}}}
+	o3 := Obj{Int: 3, Str: "Bar", IntList: []int64{1, 5, 6, 7},
+		StrIntMap:  map[string]int64{"a": 1, "b": 5},
+		NestedPtr1: nil, NestedPtr2: &nest2,
+		MapOfMaps: map[string]map[string]NestObj{"a": {"b": nest1}, "d": {}}}
+
+	diff1, err := Diff(o1, o2)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+	diff2, err := Diff(o2, o3)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	combined := ChangeSet{BaseType: diff1.BaseType}
+	combined.Changes = append(combined.Changes, diff1.Changes...)
+	// Replace the whole map, then make changes inside of it.
+	combined.Changes = append(combined.Changes,
+		NewValueChange([]PathElement{NewFieldElem(7, "StrIntMap")}, reflect.ValueOf(o2.StrIntMap), reflect.ValueOf(map[string]int64{"z": 26})),
+		NewValueAddition([]PathElement{NewFieldElem(7, "StrIntMap"), NewKeyElem("y")}, reflect.ValueOf(int64(25))),
+		NewValueDeletion([]PathElement{NewFieldElem(7, "StrIntMap"), NewKeyElem("z")}, reflect.ValueOf(int64(26))),
+		// Add and then remove a key.
+		NewValueAddition([]PathElement{NewFieldElem(12, "MapOfMaps"), NewKeyElem("x")}, reflect.ValueOf(map[string]NestObj{})),
+		NewValueDeletion([]PathElement{NewFieldElem(12, "MapOfMaps"), NewKeyElem("x")}, reflect.ValueOf(map[string]NestObj{})),
+		NewValueChange([]PathElement{NewFieldElem(7, "StrIntMap")}, reflect.ValueOf(map[string]int64{}), reflect.ValueOf(o2.StrIntMap)),
+	)
+	combined.Changes = append(combined.Changes, diff2.Changes...)
+
+	normalized, err := combined.Normalize()
+	if err != nil {
+		t.Fatalf("Error in Normalize: %v", err)
+	}
+
+	t.Logf("Combined: %v", combined.Changes)
+	t.Logf("Normalized: %v", normalized.Changes)
+	if len(normalized.Changes) >= len(combined.Changes) {
+		t.Errorf("Expected fewer changes after Normalize")
+	}
+
+	for i, c1 := range normalized.Changes {
+		for j, c2 := range normalized.Changes {
+			if i != j && hasPathPrefix(c2.GetPath(), c1.GetPath()) {
+				t.Errorf("Change %v is redundant with %v", c2, c1)
+			}
+		}
+	}
+
+	expect, err := combined.Apply(o1)
+	if err != nil {
+		t.Fatalf("Error in Apply: %v", err)
+	}
+	actual, err := normalized.Apply(o1)
+	if err != nil {
+		t.Fatalf("Error in Apply: %v", err)
+	}
+	if !reflect.DeepEqual(expect, o3) || !reflect.DeepEqual(expect, actual) {
+		t.Logf("Expect: %+v", expect)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+}
+
+func TestNormalizeOrder(t *testing.T) {
+	listPath := []PathElement{NewFieldElem(0, "A")}
+	cs := ChangeSet{
+		BaseType: reflect.TypeOf(structSlice{}),
+		Changes: []Change{
+			NewValueDeletion(extendContext(listPath, NewIndexElem(2)), reflect.ValueOf(int32(3))),
+			NewValueDeletion(extendContext(listPath, NewIndexElem(3)), reflect.ValueOf(int32(4))),
+			NewValueChange(extendContext(listPath, NewIndexElem(1)), reflect.ValueOf(int32(2)), reflect.ValueOf(int32(5))),
+			NewValueChange(extendContext(listPath, NewIndexElem(0)), reflect.ValueOf(int32(1)), reflect.ValueOf(int32(6))),
+		},
+	}
+
+	normalized, err := cs.Normalize()
+	if err != nil {
+		t.Fatalf("Error in Normalize: %v", err)
+	}
+
+	expect := []int{3, 2, 0, 1}
+	for i, change := range normalized.Changes {
+		if change.GetPath()[1].GetIndex() != expect[i] {
+			t.Fatalf("Unexpected order: %v", normalized.Changes)
+		}
+	}
+}
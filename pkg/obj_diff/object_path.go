@@ -5,24 +5,31 @@
 package obj_diff
 
 import (
+	"fmt"
 	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
 	"reflect"
 )
 
 type ObjectPathConfig struct {
 	// If the path would traverse into an object (struct, map,
-	// array, slice, pointer) that does not exist, create it.
+	// array, slice, pointer) that does not exist, create it. Otherwise a
+	// nil map, slice or pointer along the path is a PathNotFoundError.
 	CreateMissingObjects bool
 	// If the path traverses into an invalid Map key or Slice
 	// index, create the object that should be there.
 	CreateMissingValues bool
 }
 
-// var DEFAULT_CONFIG = ObjectPathConfig{false, false}
+// Returns the ObjectPathConfig used by NewObjectPath, which never creates
+// missing objects or values.
+func DefaultObjectPathConfig() ObjectPathConfig {
+	return ObjectPathConfig{false, false}
+}
 
-// func NewObjectPath(root reflect.Value, path []PathElement) *ObjectPath {
-// 	return NewObjectPathWithConfig(root, path, DEFAULT_CONFIG)
-// }
+// Creates an ObjectPath with DefaultObjectPathConfig.
+func NewObjectPath(root reflect.Value, path []PathElement) *ObjectPath {
+	return NewObjectPathWithConfig(root, path, DefaultObjectPathConfig())
+}
 
 // Creates an Object path, if root is not writable then mutating actions on
 // the resulting ObjectPath will panic. The path is a list of PathElements to
@@ -31,7 +38,7 @@ type ObjectPathConfig struct {
 func NewObjectPathWithConfig(root reflect.Value, path []PathElement, config ObjectPathConfig) *ObjectPath {
 	objectPath := &ObjectPath{Value: root, lastVals: []reflect.Value{}, index: -1, Path: path, config: config}
 	// We need to run this here because the first call to Next() will operate on the second value.
-	objectPath.nextConfigOptions(true)
+	objectPath.nextConfigOptions(len(path) > 0)
 	return objectPath
 }
 
@@ -51,6 +58,19 @@ type ObjectPath struct {
 // elements in the path, and false otherwise.
 func (op *ObjectPath) Next() (hasNext bool) {
 	op.lastVals = append(op.lastVals, op.Value)
+	switch op.Kind() {
+	case reflect.Invalid:
+		panic(PathNotFoundError{Path: op.Path[:op.index+1], ChangeIndex: -1, Reason: "missing value"})
+	case reflect.Array, reflect.Slice:
+		if !op.InBounds() {
+			panic(IndexOutOfRangeError{Path: op.Path[:op.index+2], ChangeIndex: -1, Index: op.PathElem().GetIndex(), Len: op.Len()})
+		}
+	case reflect.Ptr:
+		if op.IsNil() {
+			panic(PathNotFoundError{Path: op.Path[:op.index+1], ChangeIndex: -1, Reason: "nil pointer"})
+		}
+	}
+
 	switch op.Kind() {
 	case reflect.Struct:
 		op.Value = op.GetField()
@@ -62,11 +82,11 @@ func (op *ObjectPath) Next() (hasNext bool) {
 		op.Value = op.GetIndex()
 	case reflect.Ptr:
 		if !op.IsPointer() {
-			panic(NewPatchError("unexpected pointer in Next()"))
+			panic(PathNotFoundError{Path: op.Path[:op.index+2], ChangeIndex: -1, Reason: "expected a pointer step"})
 		}
 		op.Value = op.Elem()
 	default:
-		panic(NewPatchError("unhandled path kind '%v'\n", op.Kind()))
+		panic(UnsupportedKindError{Path: op.Path[:op.index+1], ChangeIndex: -1, Kind: op.Kind(), Op: "traverse"})
 	}
 	op.index++
 	hasNext = op.index+1 < len(op.Path)
@@ -83,7 +103,8 @@ func (op ObjectPath) nextConfigOptions(hasNext bool) {
 		if op.config.CreateMissingObjects {
 			op.CreateIfMissing()
 		}
-		if op.config.CreateMissingValues && !op.GetMapValue().IsValid() {
+		// A nil map is left missing, setting a key in it fails in Set.
+		if op.config.CreateMissingValues && hasNext && !op.IsNil() && !op.GetMapValue().IsValid() {
 			op.SetMapValueToNew(op.Type().Elem())
 		}
 	case reflect.Array:
@@ -92,7 +113,7 @@ func (op ObjectPath) nextConfigOptions(hasNext bool) {
 		if op.config.CreateMissingObjects {
 			op.CreateIfMissing()
 		}
-		if op.config.CreateMissingValues && hasNext && op.NeedsAppend() {
+		if op.config.CreateMissingValues && hasNext && !op.IsNil() && op.NeedsAppend() {
 			op.AppendNew(op.Type().Elem())
 		}
 	case reflect.Ptr:
@@ -112,10 +133,11 @@ func (op ObjectPath) LastVal() reflect.Value {
 	return op.lastVals[op.index]
 }
 
-// Retrieve the next from a Struct type. Panics if the
-// current object is not a Struct.
+// Retrieve the next from a Struct type. The field is found by name, so
+// paths recorded before fields were reordered still resolve. Panics if the
+// current object is not a Struct or the field does not exist.
 func (op *ObjectPath) GetField() reflect.Value {
-	return op.Field(op.PathElem().GetIndex())
+	return op.Field(mustFieldIndex(op.Type(), op.Path[:op.index+2]))
 }
 
 // Retrieves the next index. Panics if the current object
@@ -149,9 +171,10 @@ func (op *ObjectPath) IsPointer() bool {
 
 // Returns true if the next index is at the end of a Slice.
 // This means that an append will be successful at this point.
+// Panics with an IndexOutOfRangeError if the index is past the end.
 func (op *ObjectPath) NeedsAppend() bool {
 	if op.Len() < op.PathElem().GetIndex() {
-		panic(NewPatchError("index (%v) larger than slice size(%v)", op.PathElem().GetIndex(), op.Len()))
+		panic(IndexOutOfRangeError{Path: op.Path[:op.index+2], ChangeIndex: -1, Index: op.PathElem().GetIndex(), Len: op.Len()})
 	}
 	return op.Len() == op.PathElem().GetIndex()
 }
@@ -187,13 +210,17 @@ func (op *ObjectPath) SetToNew(newType reflect.Type) {
 	op.Set(buildNewValue(newType))
 }
 
-// Set the current value to newValue. Panics if newValue
-// is not assignable to the current value.
+// Set the current value to newValue. Panics with a TypeMismatchError
+// if newValue is not assignable to the current value.
 func (op *ObjectPath) Set(newVal reflect.Value) {
 	// fmt.Println("\n### In set() ###")
 	// fmt.Printf("CURRENT: %T, settable: %v\n", op.Interface(), op.CanSet())
 	// fmt.Printf("newVal: %+v\n", newVal)
 
+	if newVal.IsValid() && op.IsValid() && !newVal.Type().AssignableTo(op.Type()) {
+		panic(TypeMismatchError{Path: op.Path[:op.index+1], ChangeIndex: -1, Expected: op.Type(), Actual: newVal.Type()})
+	}
+
 	settable := op.Value
 	prevVal := reflect.ValueOf(nil)
 	// This loop primarily exists to backtrack to an object which is settable.
@@ -202,15 +229,19 @@ func (op *ObjectPath) Set(newVal reflect.Value) {
 	// situations.
 	for i := op.index; !settable.CanSet(); i-- {
 		if i < 0 {
-			panic("No settable object available!")
+			panic(NewPatchError("no settable object available at %v", pathString(op.Path[:op.index+1])))
 		}
 		settable = op.lastVals[i]
+		if settable.Kind() == reflect.Map && settable.IsNil() {
+			// Only created when CreateMissingObjects is set.
+			panic(PathNotFoundError{Path: op.Path[:i], ChangeIndex: -1, Reason: "nil map"})
+		}
 		// As we backtrack it is necessary to recreate the objects we have passed
 		// as they are not settable and thus copying/cloning them is the only option.
 		prevVal = CopyReflectValue(op.lastVals[i])
 		switch prevVal.Kind() {
 		case reflect.Struct:
-			prevVal.Field(op.Path[i].GetIndex()).Set(newVal)
+			prevVal.Field(mustFieldIndex(prevVal.Type(), op.Path[:i+1])).Set(newVal)
 		case reflect.Map:
 			prevVal.SetMapIndex(op.Path[i].GetKey(), newVal)
 		case reflect.Array:
@@ -220,7 +251,7 @@ func (op *ObjectPath) Set(newVal reflect.Value) {
 		case reflect.Ptr:
 			prevVal.Elem().Set(newVal)
 		default:
-			panic(NewPatchError("unhandled set-backtrack kind '%v'\n", prevVal.Kind()))
+			panic(UnsupportedKindError{Path: op.Path[:i], ChangeIndex: -1, Kind: prevVal.Kind(), Op: "set"})
 		}
 		newVal = prevVal
 
@@ -232,10 +263,18 @@ func (op *ObjectPath) Set(newVal reflect.Value) {
 	// fmt.Println("### Leaving set() ###")
 }
 
-// Delete the object at the current point in the path. Delete
-// is only supported for Map, Slice, and Ptr; panics otherwise.
+// Delete the object at the current point in the path. Deleting a Map key
+// removes it, deleting a Slice element removes it and shifts the elements
+// after it down. Struct fields, Array elements and the root can not be
+// removed so they are reset to their zero value, which for a Ptr or
+// Interface is nil.
 func (op *ObjectPath) Delete() {
 	// fmt.Println("\n### In delete() ###")
+	if op.index < 0 {
+		op.Set(reflect.Zero(op.Type()))
+		return
+	}
+
 	lastVal := op.LastVal()
 	switch lastVal.Kind() {
 	case reflect.Map:
@@ -244,15 +283,84 @@ func (op *ObjectPath) Delete() {
 			op.Set(reflect.Value{})
 		}
 	case reflect.Slice:
-		lastVal.Set(lastVal.Slice(0, lastVal.Len()-1))
+		index := op.Path[op.index].GetIndex()
+		newSlice := reflect.MakeSlice(lastVal.Type(), 0, lastVal.Len()-1)
+		newSlice = reflect.AppendSlice(newSlice, lastVal.Slice(0, index))
+		newSlice = reflect.AppendSlice(newSlice, lastVal.Slice(index+1, lastVal.Len()))
+		op.parent().Set(newSlice)
 	case reflect.Ptr:
-		lastVal.Set(reflect.Zero(lastVal.Type()))
+		op.parent().Set(reflect.Zero(lastVal.Type()))
+	case reflect.Struct, reflect.Array:
+		op.Set(reflect.Zero(op.Type()))
 	default:
-		panic(NewPatchError("unhandled delete kind '%v'", lastVal.Kind()))
+		panic(UnsupportedKindError{Path: op.Path[:op.index+1], ChangeIndex: -1, Kind: lastVal.Kind(), Op: "delete"})
 	}
 	// fmt.Println("### Leaving delete() ###")
 }
 
+// Insert newVal into the current Slice at index, shifting the elements from
+// index onwards up. An index equal to the length of the Slice appends.
+// Panics if the current object is not a Slice, or is a nil Slice and
+// CreateMissingObjects is not set.
+func (op *ObjectPath) Insert(index int, newVal reflect.Value) {
+	if op.IsNil() && !op.config.CreateMissingObjects {
+		panic(PathNotFoundError{Path: op.Path[:op.index+1], ChangeIndex: -1, Reason: "nil slice"})
+	}
+	if index < 0 || index > op.Len() {
+		panic(IndexOutOfRangeError{Path: extendContext(op.Path[:op.index+1], NewIndexElem(index)), ChangeIndex: -1, Index: index, Len: op.Len()})
+	}
+	if !newVal.IsValid() {
+		newVal = reflect.Zero(op.Type().Elem())
+	}
+
+	newSlice := reflect.MakeSlice(op.Type(), 0, op.Len()+1)
+	newSlice = reflect.AppendSlice(newSlice, op.Slice(0, index))
+	newSlice = reflect.Append(newSlice, newVal)
+	newSlice = reflect.AppendSlice(newSlice, op.Slice(index, op.Len()))
+	op.Set(newSlice)
+}
+
+// Returns a view of this ObjectPath positioned at the previous object in the
+// path, setting it backtracks in the same way as Set.
+func (op *ObjectPath) parent() *ObjectPath {
+	return &ObjectPath{Value: op.LastVal(), lastVals: op.lastVals[:op.index], index: op.index - 1, Path: op.Path, config: op.config}
+}
+
+// Returns the index of the struct field pe refers to. The field name is
+// authoritative and the recorded index is only a hint, elements without a
+// name are resolved by index.
+func fieldIndex(structType reflect.Type, pe PathElement) (int, error) {
+	index := pe.GetIndex()
+	name := pe.GetName()
+	if len(name) == 0 {
+		if index < 0 || index >= structType.NumField() {
+			return -1, fmt.Errorf("no field %v in %v", pe, structType)
+		}
+		return index, nil
+	}
+
+	if index >= 0 && index < structType.NumField() && structType.Field(index).Name == name {
+		return index, nil
+	}
+	for f := 0; f < structType.NumField(); f++ {
+		if structType.Field(f).Name == name {
+			return f, nil
+		}
+	}
+
+	return -1, fmt.Errorf("no field %v in %v", name, structType)
+}
+
+// Same as fieldIndex for the last element of path, but panics with a
+// PathNotFoundError if the field is missing.
+func mustFieldIndex(structType reflect.Type, path []PathElement) int {
+	index, err := fieldIndex(structType, path[len(path)-1])
+	if err != nil {
+		panic(PathNotFoundError{Path: path, ChangeIndex: -1, Reason: err.Error()})
+	}
+	return index
+}
+
 // Build a new value of type newType.
 func buildNewValue(newType reflect.Type) (newValue reflect.Value) {
 	// fmt.Printf("Building new %v\n", newType)
@@ -0,0 +1,134 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+)
+
+// Returns the value found by following path through obj, which may be a
+// value or a pointer as given to Diff. The object is never modified, an
+// error is returned if any step along path does not exist.
+func Get(obj interface{}, path []PathElement) (interface{}, error) {
+	value, err := lookupPath(reflect.ValueOf(obj), path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !value.IsValid() {
+		return nil, nil
+	} else if !value.CanInterface() {
+		return nil, UnsupportedKindError{Path: path, ChangeIndex: -1, Kind: value.Kind(), Op: "read unexported"}
+	}
+	return value.Interface(), nil
+}
+
+// Returns true if every step along path exists in obj.
+func Exists(obj interface{}, path []PathElement) bool {
+	_, err := lookupPath(reflect.ValueOf(obj), path)
+	return err == nil
+}
+
+// Calls fn with the path and value of every leaf in obj, without modifying
+// it. Leaves are values that can not be traversed further: basic values,
+// nil pointers, empty or nil maps and slices, and interfaces. Structs with
+// unexported fields are leaves, as Diff compares them whole, and so are
+// pointers back to an object containing them. Map keys are visited in the
+// same order Diff uses. If fn returns an error the walk stops and that error
+// is returned.
+func Walk(obj interface{}, fn func(path []PathElement, value reflect.Value) error) error {
+	return walkValue(reflect.ValueOf(obj), []PathElement{}, map[aliasKey]bool{}, fn)
+}
+
+// The pointers being walked through are in visiting, so cycles end there.
+func walkValue(value reflect.Value, path []PathElement, visiting map[aliasKey]bool, fn func([]PathElement, reflect.Value) error) error {
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.NumField() > 0 && !hasUnexportedFields(value.Type()) {
+			for f := 0; f < value.NumField(); f++ {
+				fieldPath := extendContext(path, NewFieldElem(f, value.Type().Field(f).Name))
+				if err := walkValue(value.Field(f), fieldPath, visiting, fn); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case reflect.Map:
+		if value.Len() > 0 {
+			for _, key := range sortedMapKeys(value, value) {
+				if err := walkValue(value.MapIndex(key), extendContext(path, NewKeyElem(key)), visiting, fn); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case reflect.Array, reflect.Slice:
+		if value.Len() > 0 {
+			for i := 0; i < value.Len(); i++ {
+				if err := walkValue(value.Index(i), extendContext(path, NewIndexElem(i)), visiting, fn); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case reflect.Ptr:
+		key := aliasKey{value.Pointer(), value.Type()}
+		if !value.IsNil() && !visiting[key] {
+			visiting[key] = true
+			defer delete(visiting, key)
+			return walkValue(value.Elem(), extendContext(path, NewPtrElem()), visiting, fn)
+		}
+	}
+
+	return fn(path, value)
+}
+
+// Follows path from root without modifying anything along the way. Returns
+// an error if any step does not exist, such as a missing map key, an index
+// out of range or a nil pointer.
+func lookupPath(root reflect.Value, path []PathElement) (reflect.Value, error) {
+	curr := root
+	for i, pe := range path {
+		at := path[:i+1]
+		switch curr.Kind() {
+		case reflect.Struct:
+			index, err := fieldIndex(curr.Type(), pe)
+			if err != nil {
+				return reflect.Value{}, PathNotFoundError{Path: at, ChangeIndex: -1, Reason: err.Error()}
+			}
+			curr = curr.Field(index)
+		case reflect.Map:
+			key := pe.GetKey()
+			if !key.IsValid() || !key.Type().AssignableTo(curr.Type().Key()) {
+				return reflect.Value{}, TypeMismatchError{Path: at, ChangeIndex: -1, Expected: curr.Type().Key(), Actual: reflect.TypeOf(pe.GetKey())}
+			}
+			next := curr.MapIndex(key)
+			if !next.IsValid() {
+				return reflect.Value{}, PathNotFoundError{Path: at, ChangeIndex: -1, Reason: "missing key"}
+			}
+			curr = next
+		case reflect.Array, reflect.Slice:
+			if pe.GetIndex() < 0 || pe.GetIndex() >= curr.Len() {
+				return reflect.Value{}, IndexOutOfRangeError{Path: at, ChangeIndex: -1, Index: pe.GetIndex(), Len: curr.Len()}
+			}
+			curr = curr.Index(pe.GetIndex())
+		case reflect.Ptr:
+			if !pe.IsPointer() {
+				return reflect.Value{}, PathNotFoundError{Path: at, ChangeIndex: -1, Reason: "expected a pointer step"}
+			}
+			if curr.IsNil() {
+				return reflect.Value{}, PathNotFoundError{Path: at, ChangeIndex: -1, Reason: "nil pointer"}
+			}
+			curr = curr.Elem()
+		case reflect.Invalid:
+			return reflect.Value{}, PathNotFoundError{Path: at, ChangeIndex: -1, Reason: "missing value"}
+		default:
+			return reflect.Value{}, UnsupportedKindError{Path: at, ChangeIndex: -1, Kind: curr.Kind(), Op: "traverse"}
+		}
+	}
+
+	return curr, nil
+}
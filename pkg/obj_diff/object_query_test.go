@@ -0,0 +1,131 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGet(t *testing.T) {
+	o1, _ := buildQueryObjects()
+	original := CopyValueReflectively(o1)
+	mapOfMaps := NewFieldElem(12, "MapOfMaps")
+	tests := []struct {
+		path   []PathElement
+		expect interface{}
+		err    bool
+	}{
+		{path: []PathElement{}, expect: o1},
+		{path: []PathElement{NewFieldElem(3, "Str")}, expect: "Foo"},
+		{path: []PathElement{mapOfMaps, NewKeyElem("a"), NewKeyElem("image"), NewFieldElem(1, "Str")}, expect: "A"},
+		{path: []PathElement{NewFieldElem(9, "NestedPtr1"), NewPtrElem(), NewFieldElem(0, "Int")}, expect: int64(9)},
+		{path: []PathElement{mapOfMaps, NewKeyElem("missing"), NewKeyElem("image")}, err: true},
+		{path: []PathElement{NewFieldElem(10, "NestedPtr2"), NewPtrElem(), NewFieldElem(0, "Int")}, err: true},
+		{path: []PathElement{NewFieldElem(5, "IntList"), NewIndexElem(0)}, err: true},
+		{path: []PathElement{NewFieldElem(0, "Int"), NewIndexElem(0)}, err: true},
+	}
+	for _, test := range tests {
+		actual, err := Get(o1, test.path)
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected an error for %v, got: %v", pathString(test.path), actual)
+			}
+			if Exists(o1, test.path) {
+				t.Errorf("Expected %v to not exist", pathString(test.path))
+			}
+			continue
+		}
+
+		if err != nil || !reflect.DeepEqual(test.expect, actual) {
+			t.Logf("Expect: %+v", test.expect)
+			t.Logf("Actual: %+v, %v", actual, err)
+			t.Fail()
+		}
+		if !Exists(o1, test.path) {
+			t.Errorf("Expected %v to exist", pathString(test.path))
+		}
+	}
+
+	if !reflect.DeepEqual(original, o1) {
+		t.Errorf("Get modified the object")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	nest := NestObj{1, "A"}
+	obj := struct {
+		Ptr   *NestObj
+		Nil   *NestObj
+		List  []int
+		Empty map[string]int
+		Map   map[string]int
+	}{Ptr: &nest, List: []int{5, 6}, Map: map[string]int{"b": 2, "a": 1}}
+
+	actual := []string{}
+	err := Walk(&obj, func(path []PathElement, value reflect.Value) error {
+		actual = append(actual, pathString(path))
+		if got, err := Get(&obj, path); err != nil || !reflect.DeepEqual(got, value.Interface()) {
+			t.Errorf("Expected Get at %v to return %v, got: %v, %v", pathString(path), value, got, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error in Walk: %v", err)
+	}
+
+	expect := []string{
+		"*.Ptr(0)*.Int(0)",
+		"*.Ptr(0)*.Str(1)",
+		"*.Nil(1)",
+		"*.List(2)[0]",
+		"*.List(2)[1]",
+		"*.Empty(3)",
+		`*.Map(4){"a"}`,
+		`*.Map(4){"b"}`,
+	}
+	if !reflect.DeepEqual(expect, actual) {
+		t.Logf("Expect: %v", expect)
+		t.Logf("Actual: %v", actual)
+		t.Fail()
+	}
+}
+
+type walkNode struct {
+	Name    string
+	Next    *walkNode
+	Created time.Time
+}
+
+func TestWalkLeaves(t *testing.T) {
+	node := &walkNode{Name: "a", Created: time.Now()}
+	node.Next = &walkNode{Name: "b", Next: node}
+
+	actual := []string{}
+	err := Walk(node, func(path []PathElement, value reflect.Value) error {
+		actual = append(actual, pathString(path))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error in Walk: %v", err)
+	}
+
+	// The cycle ends at the pointer back to the root, and time.Time is
+	// compared whole by Diff.
+	expect := []string{
+		"*.Name(0)",
+		"*.Next(1)*.Name(0)",
+		"*.Next(1)*.Next(1)",
+		"*.Next(1)*.Created(2)",
+		"*.Created(2)",
+	}
+	if !reflect.DeepEqual(expect, actual) {
+		t.Logf("Expect: %v", expect)
+		t.Logf("Actual: %v", actual)
+		t.Fail()
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Parses a path in the format produced by Change.PathString() and validates
+// it against typ:
+//
+//	.Name(3)   a struct field, the index is optional and the field is
+//	           always resolved by name
+//	{"key"}    a map key, encoded as JSON and decoded into the key type.
+//	           Keys of string kind may also be written bare, as in {key}
+//	[3]        an array or slice index
+//	*          a pointer, may be omitted when the type is a pointer
+//
+// Map keys of interface type must be JSON strings or booleans, the type of
+// any other key is not recorded so it would not decode to the same key.
+// Infinite float keys are written as +Inf and -Inf, NaN keys can not be
+// parsed as they never equal a key in a map.
+func ParsePath(typ reflect.Type, path string) ([]PathElement, error) {
+	if typ == nil {
+		return nil, fmt.Errorf("invalid path %q: no type to parse against", path)
+	}
+
+	elems := []PathElement{}
+	currType := typ
+	for pos := 0; pos < len(path); {
+		if path[pos] == '*' {
+			if currType.Kind() != reflect.Ptr {
+				return nil, fmt.Errorf("invalid path %q: %v is not a pointer at %v", path, currType, pos)
+			}
+			elems = append(elems, NewPtrElem())
+			currType = currType.Elem()
+			pos++
+			continue
+		}
+
+		// Pointers are implied by the element that follows.
+		for currType.Kind() == reflect.Ptr {
+			elems = append(elems, NewPtrElem())
+			currType = currType.Elem()
+		}
+
+		var elem PathElement
+		var err error
+		switch path[pos] {
+		case '.':
+			elem, currType, pos, err = parseFieldElem(currType, path, pos+1)
+		case '{':
+			elem, currType, pos, err = parseKeyElem(currType, path, pos+1)
+		case '[':
+			elem, currType, pos, err = parseIndexElem(currType, path, pos+1)
+		default:
+			err = fmt.Errorf("unexpected '%c' at %v", path[pos], pos)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %v", path, err)
+		}
+		elems = append(elems, elem)
+	}
+
+	return elems, nil
+}
+
+func parseFieldElem(currType reflect.Type, path string, pos int) (PathElement, reflect.Type, int, error) {
+	end := pos
+	for end < len(path) && isIdentChar(path[end]) {
+		end++
+	}
+	if end == pos {
+		return nil, nil, pos, fmt.Errorf("missing field name at %v", pos)
+	}
+	name := path[pos:end]
+
+	if end < len(path) && path[end] == '(' {
+		closing := strings.IndexByte(path[end:], ')')
+		if closing < 0 {
+			return nil, nil, pos, fmt.Errorf("unterminated field index at %v", end)
+		}
+		if _, err := strconv.Atoi(path[end+1 : end+closing]); err != nil {
+			return nil, nil, pos, fmt.Errorf("invalid field index at %v: %v", end, err)
+		}
+		end += closing + 1
+	}
+
+	if currType.Kind() != reflect.Struct {
+		return nil, nil, pos, fmt.Errorf("%v is not a struct at %v", currType, pos)
+	}
+	field, ok := currType.FieldByName(name)
+	if !ok || len(field.Index) != 1 {
+		return nil, nil, pos, fmt.Errorf("no field %v in %v at %v", name, currType, pos)
+	}
+
+	return NewFieldElem(field.Index[0], name), field.Type, end, nil
+}
+
+func parseKeyElem(currType reflect.Type, path string, pos int) (PathElement, reflect.Type, int, error) {
+	if currType.Kind() != reflect.Map {
+		return nil, nil, pos, fmt.Errorf("%v is not a map at %v", currType, pos)
+	}
+
+	keyType := currType.Key()
+	key := reflect.New(keyType)
+	end := pos
+	closing := strings.IndexByte(path[pos:], '}')
+	switch {
+	case keyType.Kind() == reflect.String && pos < len(path) && path[pos] != '"':
+		if closing < 0 {
+			return nil, nil, pos, fmt.Errorf("unterminated key at %v", pos)
+		}
+		key.Elem().SetString(path[pos : pos+closing])
+		end = pos + closing
+	case isFloatKind(keyType.Kind()) && closing > 0 && isNonFiniteKey(path[pos:pos+closing]):
+		// These are not valid JSON, String formats them with fmt.
+		value, _ := strconv.ParseFloat(path[pos:pos+closing], 64)
+		if math.IsNaN(value) {
+			return nil, nil, pos, fmt.Errorf("NaN key at %v never equals a key in a map", pos)
+		}
+		key.Elem().SetFloat(value)
+		end = pos + closing
+	default:
+		decoder := json.NewDecoder(strings.NewReader(path[pos:]))
+		if err := decoder.Decode(key.Interface()); err != nil {
+			return nil, nil, pos, fmt.Errorf("invalid %v key at %v: %v", keyType, pos, err)
+		}
+		end = pos + int(decoder.InputOffset())
+		for end < len(path) && path[end] == ' ' {
+			end++
+		}
+		if end >= len(path) || path[end] != '}' {
+			return nil, nil, pos, fmt.Errorf("expected '}' at %v", end)
+		}
+	}
+
+	if keyType.Kind() == reflect.Interface {
+		switch key.Elem().Elem().Kind() {
+		case reflect.String, reflect.Bool:
+		default:
+			return nil, nil, pos, fmt.Errorf("ambiguous %v key at %v, only strings and booleans keep their type", keyType, pos)
+		}
+	}
+
+	return NewKeyElem(key.Elem().Interface()), currType.Elem(), end + 1, nil
+}
+
+// Returns true if s is a float formatted by fmt which JSON can not encode.
+func isNonFiniteKey(s string) bool {
+	return s == "NaN" || s == "+Inf" || s == "-Inf"
+}
+
+func parseIndexElem(currType reflect.Type, path string, pos int) (PathElement, reflect.Type, int, error) {
+	closing := strings.IndexByte(path[pos:], ']')
+	if closing < 0 {
+		return nil, nil, pos, fmt.Errorf("unterminated index at %v", pos)
+	}
+	index, err := strconv.Atoi(path[pos : pos+closing])
+	if err != nil || index < 0 {
+		return nil, nil, pos, fmt.Errorf("invalid index %q at %v", path[pos:pos+closing], pos)
+	}
+
+	switch currType.Kind() {
+	case reflect.Array:
+		if index >= currType.Len() {
+			return nil, nil, pos, fmt.Errorf("index %v out of range for %v at %v", index, currType, pos)
+		}
+	case reflect.Slice:
+	default:
+		return nil, nil, pos, fmt.Errorf("%v is not an array or slice at %v", currType, pos)
+	}
+
+	return NewIndexElem(index), currType.Elem(), pos + closing + 1, nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type keyedObj struct {
+	IntKeys    map[int]string
+	StructKeys map[NestObj]bool
+	Nested     *keyedObj
+}
+
+func TestParsePathRoundTrip(t *testing.T) {
+	o1, o2 := buildQueryObjects()
+	o2.StrIntMap["quote\"}{"] = 3
+	o2.IntList = []int64{1, 2}
+	diff, err := Diff(&o1, &o2)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	for _, change := range diff.Changes {
+		path, err := ParsePath(diff.BaseType, change.PathString())
+		if err != nil {
+			t.Errorf("Error parsing %v: %v", change.PathString(), err)
+		} else if !pathsEqual(path, change.GetPath()) {
+			t.Logf("Expect: %v", change.PathString())
+			t.Logf("Actual: %v", pathString(path))
+			t.Fail()
+		}
+	}
+
+	typedPath := []PathElement{NewFieldElem(2, "Nested"), NewPtrElem(), NewFieldElem(0, "IntKeys"), NewKeyElem(12)}
+	path, err := ParsePath(reflect.TypeOf(keyedObj{}), pathString(typedPath))
+	if err != nil || !pathsEqual(path, typedPath) {
+		t.Errorf("Expected %v, got: %v, %v", pathString(typedPath), path, err)
+	}
+
+	structPath := []PathElement{NewFieldElem(1, "StructKeys"), NewKeyElem(NestObj{1, "A"})}
+	path, err = ParsePath(reflect.TypeOf(keyedObj{}), pathString(structPath))
+	if err != nil || !pathsEqual(path, structPath) {
+		t.Errorf("Expected %v, got: %v, %v", pathString(structPath), path, err)
+	}
+}
+
+type dynamicKeyObj struct {
+	Any    map[interface{}]string
+	Floats map[float64]string
+}
+
+func TestParsePathKeys(t *testing.T) {
+	objType := reflect.TypeOf(dynamicKeyObj{})
+	roundTrips := [][]PathElement{
+		{NewFieldElem(0, "Any"), NewKeyElem("a")},
+		{NewFieldElem(0, "Any"), NewKeyElem(true)},
+		{NewFieldElem(1, "Floats"), NewKeyElem(1.5)},
+		{NewFieldElem(1, "Floats"), NewKeyElem(math.Inf(1))},
+		{NewFieldElem(1, "Floats"), NewKeyElem(math.Inf(-1))},
+	}
+	for _, expect := range roundTrips {
+		actual, err := ParsePath(objType, pathString(expect))
+		if err != nil || !pathsEqual(expect, actual) {
+			t.Errorf("Expected %v, got: %v, %v", pathString(expect), actual, err)
+		}
+	}
+
+	// The type of these keys can not be recovered from the path.
+	failing := [][]PathElement{
+		{NewFieldElem(0, "Any"), NewKeyElem(3)},
+		{NewFieldElem(0, "Any"), NewKeyElem(NestObj{1, "A"})},
+		{NewFieldElem(1, "Floats"), NewKeyElem(math.NaN())},
+	}
+	for _, path := range failing {
+		if actual, err := ParsePath(objType, pathString(path)); err == nil {
+			t.Errorf("Expected an error parsing %v, got: %v", pathString(path), actual)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	objType := reflect.TypeOf(Obj{})
+	tests := []struct {
+		path   string
+		expect []PathElement
+		err    bool
+	}{
+		{path: "", expect: []PathElement{}},
+		{path: ".MapOfMaps{a}{b}.Int", expect: []PathElement{NewFieldElem(12, "MapOfMaps"), NewKeyElem("a"), NewKeyElem("b"), NewFieldElem(0, "Int")}},
+		{path: ".NestedPtr1.Str", expect: []PathElement{NewFieldElem(9, "NestedPtr1"), NewPtrElem(), NewFieldElem(1, "Str")}},
+		{path: ".BoolList[2]", expect: []PathElement{NewFieldElem(6, "BoolList"), NewIndexElem(2)}},
+		{path: ".Str(99)", expect: []PathElement{NewFieldElem(3, "Str")}},
+		{path: ".Missing", err: true},
+		{path: ".BoolList[3]", err: true},
+		{path: ".Int*", err: true},
+		{path: ".StrIntMap[1]", err: true},
+		{path: ".StrIntMap{\"a}", err: true},
+		{path: "Int", err: true},
+	}
+	for _, test := range tests {
+		actual, err := ParsePath(objType, test.path)
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected an error for %q, got: %v", test.path, pathString(actual))
+			}
+			continue
+		}
+
+		if err != nil || !pathsEqual(test.expect, actual) {
+			t.Logf("Expect: %v", pathString(test.expect))
+			t.Logf("Actual: %v, %v", actual, err)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"fmt"
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"strconv"
+	"strings"
+)
+
+// A PathPattern matches paths using a syntax similar to PathString:
+//
+//	.Name      a struct field by name, .* matches any field
+//	.Name(3)   a struct field by name and index
+//	{glob}     a map key, compared against the formatted key. Keys may
+//	           be quoted, as in {"a.b"}
+//	[3]        an array or slice index, [*] matches any index
+//	*          a pointer, pointers are transparent and may be omitted
+//	**         zero or more elements of any kind
+//
+// Globs support '*' to match any run of characters and '?' to match a
+// single character. For example ".Spec.Template**" matches every change
+// below Spec.Template and "**{image}" matches a map key named image
+// anywhere in the object.
+type PathPattern struct {
+	pattern  string
+	segments []patternSegment
+}
+
+type segmentKind int
+
+const (
+	fieldSegment segmentKind = iota
+	keySegment
+	indexSegment
+	anySegment
+)
+
+type patternSegment struct {
+	kind  segmentKind
+	glob  string
+	index int
+}
+
+// Parse pattern into a PathPattern.
+func NewPathPattern(pattern string) (*PathPattern, error) {
+	pp := &PathPattern{pattern: pattern}
+	for pos := 0; pos < len(pattern); {
+		var seg patternSegment
+		var err error
+		switch pattern[pos] {
+		case '.':
+			seg, pos, err = parseFieldSegment(pattern, pos+1)
+		case '{':
+			seg, pos, err = parseKeySegment(pattern, pos+1)
+		case '[':
+			seg, pos, err = parseIndexSegment(pattern, pos+1)
+		case '*':
+			if strings.HasPrefix(pattern[pos:], "**") {
+				pp.segments = append(pp.segments, patternSegment{kind: anySegment})
+				pos += 2
+			} else {
+				// Pointers are transparent.
+				pos++
+			}
+			continue
+		default:
+			err = fmt.Errorf("unexpected '%c'", pattern[pos])
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid path pattern %q: %v", pattern, err)
+		}
+		pp.segments = append(pp.segments, seg)
+	}
+
+	return pp, nil
+}
+
+func parseFieldSegment(pattern string, pos int) (patternSegment, int, error) {
+	seg := patternSegment{kind: fieldSegment, index: -1}
+	if strings.HasPrefix(pattern[pos:], "*") && !strings.HasPrefix(pattern[pos:], "**") {
+		seg.glob = "*"
+		return seg, pos + 1, nil
+	}
+
+	end := pos
+	for end < len(pattern) && (isIdentChar(pattern[end]) || pattern[end] == '?') {
+		end++
+	}
+	if end == pos {
+		return seg, pos, fmt.Errorf("missing field name at %v", pos)
+	}
+	seg.glob = pattern[pos:end]
+
+	if end < len(pattern) && pattern[end] == '(' {
+		closing := strings.IndexByte(pattern[end:], ')')
+		if closing < 0 {
+			return seg, pos, fmt.Errorf("unterminated field index at %v", end)
+		}
+		index, err := strconv.Atoi(pattern[end+1 : end+closing])
+		if err != nil {
+			return seg, pos, fmt.Errorf("invalid field index at %v: %v", end, err)
+		}
+		seg.index = index
+		end += closing + 1
+	}
+
+	return seg, end, nil
+}
+
+func parseKeySegment(pattern string, pos int) (patternSegment, int, error) {
+	seg := patternSegment{kind: keySegment}
+	if pos < len(pattern) && pattern[pos] == '"' {
+		quoted, err := strconv.QuotedPrefix(pattern[pos:])
+		if err != nil {
+			return seg, pos, fmt.Errorf("invalid quoted key at %v: %v", pos, err)
+		}
+		seg.glob, _ = strconv.Unquote(quoted)
+		pos += len(quoted)
+		if pos >= len(pattern) || pattern[pos] != '}' {
+			return seg, pos, fmt.Errorf("expected '}' at %v", pos)
+		}
+		return seg, pos + 1, nil
+	}
+
+	closing := strings.IndexByte(pattern[pos:], '}')
+	if closing < 0 {
+		return seg, pos, fmt.Errorf("unterminated key at %v", pos)
+	}
+	seg.glob = pattern[pos : pos+closing]
+	return seg, pos + closing + 1, nil
+}
+
+func parseIndexSegment(pattern string, pos int) (patternSegment, int, error) {
+	seg := patternSegment{kind: indexSegment, index: -1}
+	closing := strings.IndexByte(pattern[pos:], ']')
+	if closing < 0 {
+		return seg, pos, fmt.Errorf("unterminated index at %v", pos)
+	}
+
+	content := pattern[pos : pos+closing]
+	if content != "*" {
+		index, err := strconv.Atoi(content)
+		if err != nil || index < 0 {
+			return seg, pos, fmt.Errorf("invalid index %q at %v", content, pos)
+		}
+		seg.index = index
+	}
+
+	return seg, pos + closing + 1, nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (pp PathPattern) String() string {
+	return pp.pattern
+}
+
+// Returns true if the whole of path matches this pattern.
+func (pp PathPattern) Match(path []PathElement) bool {
+	return matchSegments(pp.segments, withoutPointers(path))
+}
+
+// Returns true if path is the start of a path this pattern could match.
+func (pp PathPattern) MatchPrefix(path []PathElement) bool {
+	return matchSegmentsPrefix(pp.segments, withoutPointers(path))
+}
+
+func matchSegmentsPrefix(segments []patternSegment, path []PathElement) bool {
+	if len(path) == 0 {
+		return true
+	}
+	if len(segments) == 0 {
+		return false
+	}
+
+	seg := segments[0]
+	if seg.kind == anySegment {
+		// The remaining elements could all be consumed here.
+		return true
+	}
+	return seg.matchElem(path[0]) && matchSegmentsPrefix(segments[1:], path[1:])
+}
+
+func matchSegments(segments []patternSegment, path []PathElement) bool {
+	if len(segments) == 0 {
+		return len(path) == 0
+	}
+
+	seg := segments[0]
+	if seg.kind == anySegment {
+		for skip := 0; skip <= len(path); skip++ {
+			if matchSegments(segments[1:], path[skip:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(path) == 0 || !seg.matchElem(path[0]) {
+		return false
+	}
+	return matchSegments(segments[1:], path[1:])
+}
+
+// Returns true if a single non-pointer PathElement matches this segment.
+func (seg patternSegment) matchElem(pe PathElement) bool {
+	switch seg.kind {
+	case fieldSegment:
+		if len(pe.GetName()) == 0 {
+			return false
+		}
+		return globMatch(seg.glob, pe.GetName()) && (seg.index < 0 || seg.index == pe.GetIndex())
+	case keySegment:
+		key := pe.GetKey()
+		if !key.IsValid() {
+			return false
+		}
+		return globMatch(seg.glob, fmt.Sprint(key.Interface()))
+	case indexSegment:
+		if pe.GetKey().IsValid() || len(pe.GetName()) > 0 || pe.GetIndex() < 0 {
+			return false
+		}
+		return seg.index < 0 || seg.index == pe.GetIndex()
+	}
+
+	return false
+}
+
+// Removes the pointer steps from path.
+func withoutPointers(path []PathElement) []PathElement {
+	stripped := make([]PathElement, 0, len(path))
+	for _, pe := range path {
+		if !pe.IsPointer() {
+			stripped = append(stripped, pe)
+		}
+	}
+	return stripped
+}
+
+// A minimal glob supporting '*' for any run of characters and '?' for any
+// single character.
+func globMatch(glob string, str string) bool {
+	if len(glob) == 0 {
+		return len(str) == 0
+	}
+
+	switch glob[0] {
+	case '*':
+		for skip := 0; skip <= len(str); skip++ {
+			if globMatch(glob[1:], str[skip:]) {
+				return true
+			}
+		}
+		return false
+	case '?':
+		return len(str) > 0 && globMatch(glob[1:], str[1:])
+	}
+
+	return len(str) > 0 && glob[0] == str[0] && globMatch(glob[1:], str[1:])
+}
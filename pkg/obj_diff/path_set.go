@@ -0,0 +1,140 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"fmt"
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+)
+
+// Sets the value at path within the object obj points to, creating any
+// missing maps, slices and pointers along the way in the same way as Patch.
+// The path uses the ParsePath syntax, the leading '.' may be omitted as in
+// "Spec.Replicas" or "Metadata.Labels{team}". The value is converted to the
+// type at path if it is of the same kind, see SetPathWithConfig.
+func SetPath(obj interface{}, path string, value interface{}) error {
+	return SetPathWithConfig(obj, path, value, ObjectPathConfig{true, true})
+}
+
+// Same as SetPath, config controls whether missing objects and values along
+// the path are created or cause an error. Values are converted between
+// integer kinds when they fit, from integers to floats, between floats, and
+// to named types of the same kind. A nil value sets the zero value of
+// pointers, maps, slices and interfaces.
+func SetPathWithConfig(obj interface{}, path string, value interface{}, config ObjectPathConfig) error {
+	cs, elems, err := pathChangeSet(obj, path)
+	if err != nil {
+		return err
+	}
+
+	targetType, err := typeAtPath(cs.BaseType, elems)
+	if err != nil {
+		return err
+	}
+	newValue, err := convertValue(reflect.ValueOf(value), targetType)
+	if err != nil {
+		return TypeMismatchError{Path: elems, ChangeIndex: -1, Expected: targetType, Actual: reflect.TypeOf(value)}
+	}
+
+	cs.AddPathChange(elems, reflect.Value{}, newValue)
+	return cs.applyChangesWithOptions(reflect.ValueOf(obj), PatchOptions{ObjectPathConfig: config})
+}
+
+// Deletes the value at path within the object obj points to, using the same
+// path syntax as SetPath. Map keys and slice elements are removed, anything
+// else is reset to its zero value. Nothing missing along the path is created, it
+// is an error for anything but the final map key to be missing.
+func DeletePath(obj interface{}, path string) error {
+	cs, elems, err := pathChangeSet(obj, path)
+	if err != nil {
+		return err
+	}
+	if len(elems) < 2 {
+		return fmt.Errorf("can not delete the whole object")
+	}
+
+	// Pointer fields are deleted by their pointer step, the same as in Diff.
+	targetType, err := typeAtPath(cs.BaseType, elems)
+	if err == nil && targetType.Kind() == reflect.Ptr && len(elems[len(elems)-1].GetName()) > 0 {
+		elems = extendContext(elems, NewPtrElem())
+	}
+
+	cs.AddPathDeletion(elems, reflect.Value{})
+	return cs.applyChangesWithOptions(reflect.ValueOf(obj), PatchOptions{ObjectPathConfig: DefaultObjectPathConfig()})
+}
+
+// Validates obj and parses path against the type it points to. The returned
+// path starts with the pointer step into obj, and the ChangeSet is empty.
+func pathChangeSet(obj interface{}, path string) (ChangeSet, []PathElement, error) {
+	objVal := reflect.ValueOf(obj)
+	if objVal.Kind() != reflect.Ptr || objVal.IsNil() {
+		return ChangeSet{}, nil, fmt.Errorf("can not set obj of Type: %T", obj)
+	}
+
+	if len(path) > 0 && path[0] != '.' && path[0] != '{' && path[0] != '[' && path[0] != '*' {
+		path = "." + path
+	}
+	elems, err := ParsePath(objVal.Type().Elem(), path)
+	if err != nil {
+		return ChangeSet{}, nil, err
+	}
+
+	return ChangeSet{BaseType: objVal.Type()}, append([]PathElement{NewPtrElem()}, elems...), nil
+}
+
+// Converts value to targetType, allowing only conversions which keep the
+// meaning of the value.
+func convertValue(value reflect.Value, targetType reflect.Type) (reflect.Value, error) {
+	if !value.IsValid() {
+		switch targetType.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+			return reflect.Zero(targetType), nil
+		}
+		return reflect.Value{}, fmt.Errorf("nil is not a valid %v", targetType)
+	}
+
+	if value.Type().AssignableTo(targetType) {
+		return value, nil
+	}
+
+	// Values of the type pointed to are set through a new pointer.
+	if targetType.Kind() == reflect.Ptr {
+		if elem, err := convertValue(value, targetType.Elem()); err == nil {
+			ptr := reflect.New(targetType.Elem())
+			ptr.Elem().Set(elem)
+			return ptr, nil
+		}
+	}
+
+	switch {
+	case isIntKind(value.Kind()) && isIntKind(targetType.Kind()):
+		// The conversion must survive a round trip without changing sign.
+		converted := value.Convert(targetType)
+		if converted.Convert(value.Type()).Interface() != value.Interface() || isNegative(converted) != isNegative(value) {
+			return reflect.Value{}, fmt.Errorf("%v overflows %v", value.Interface(), targetType)
+		}
+		return converted, nil
+	case isIntKind(value.Kind()) && isFloatKind(targetType.Kind()),
+		isFloatKind(value.Kind()) && isFloatKind(targetType.Kind()):
+		return value.Convert(targetType), nil
+	case value.Kind() == targetType.Kind() && value.Type().ConvertibleTo(targetType):
+		return value.Convert(targetType), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("%v is not a valid %v", value.Type(), targetType)
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Uintptr
+}
+
+func isNegative(value reflect.Value) bool {
+	return value.Kind() < reflect.Uint && value.Int() < 0
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
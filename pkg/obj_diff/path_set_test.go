@@ -0,0 +1,102 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSetPath(t *testing.T) {
+	four := int16(4)
+	actual := Obj{IntPtr: &four, StrIntMap: map[string]int64{"a": 1}}
+	sets := []struct {
+		path  string
+		value interface{}
+	}{
+		{path: "Int", value: 7},
+		{path: ".Float", value: 2},
+		{path: "IntPtr", value: 5},
+		{path: "StrIntMap{b}", value: uint8(2)},
+		{path: `MapOfMaps{"x"}{y}.Str`, value: "Hello"},
+		{path: "NestedPtr1.Int", value: int64(3)},
+		{path: "IntList[0]", value: 9},
+		{path: "NestedPtr2", value: NestObj{1, "A"}},
+	}
+	for _, set := range sets {
+		if err := SetPath(&actual, set.path, set.value); err != nil {
+			t.Fatalf("Error in SetPath(%v): %v", set.path, err)
+		}
+	}
+
+	five := int16(5)
+	expect := Obj{Int: 7, Float: 2, IntPtr: &five,
+		StrIntMap:  map[string]int64{"a": 1, "b": 2},
+		MapOfMaps:  map[string]map[string]NestObj{"x": {"y": {Str: "Hello"}}},
+		NestedPtr1: &NestObj{Int: 3}, NestedPtr2: &NestObj{1, "A"},
+		IntList: []int64{9}}
+	if !reflect.DeepEqual(expect, actual) {
+		t.Logf("Expect: %+v", expect)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+	if four != 4 {
+		t.Errorf("SetPath modified the value IntPtr pointed to")
+	}
+
+	failing := []struct {
+		path  string
+		value interface{}
+	}{
+		{path: "Int", value: "7"},
+		{path: "Int", value: 1 << 40},
+		{path: "Str", value: 7},
+		{path: "Bool", value: nil},
+		{path: "Missing", value: 1},
+		{path: "IntList[5]", value: 1},
+	}
+	for _, set := range failing {
+		if err := SetPath(&actual, set.path, set.value); err == nil {
+			t.Errorf("Expected an error for SetPath(%v, %v)", set.path, set.value)
+		}
+	}
+
+	if err := SetPathWithConfig(&actual, "NestedPtr3.Int", 1, DefaultObjectPathConfig()); err == nil {
+		t.Errorf("Expected an error setting below a nil pointer without CreateMissingObjects")
+	}
+	if actual.NestedPtr3 != nil {
+		t.Errorf("Expected NestedPtr3 to still be nil, got: %+v", actual.NestedPtr3)
+	}
+	actual.StrIntMap = nil
+	if err := SetPathWithConfig(&actual, "StrIntMap{a}", 1, DefaultObjectPathConfig()); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("Expected a PathNotFoundError setting in a nil map without CreateMissingObjects, got: %v", err)
+	}
+}
+
+func TestDeletePath(t *testing.T) {
+	nest := NestObj{1, "A"}
+	actual := Obj{IntList: []int64{1, 2, 3}, StrIntMap: map[string]int64{"a": 1, "b": 2},
+		NestedPtr1: &nest, MapOfMaps: map[string]map[string]NestObj{"x": {"y": nest, "z": nest}}}
+	for _, path := range []string{"IntList[1]", "StrIntMap{a}", "NestedPtr1", "MapOfMaps{x}{y}", "StrIntMap{missing}"} {
+		if err := DeletePath(&actual, path); err != nil {
+			t.Fatalf("Error in DeletePath(%v): %v", path, err)
+		}
+	}
+
+	expect := Obj{IntList: []int64{1, 3}, StrIntMap: map[string]int64{"b": 2},
+		MapOfMaps: map[string]map[string]NestObj{"x": {"z": nest}}}
+	if !reflect.DeepEqual(expect, actual) {
+		t.Logf("Expect: %+v", expect)
+		t.Logf("Actual: %+v", actual)
+		t.Fail()
+	}
+
+	for _, path := range []string{"", "NestedPtr2.Int", "MapOfMaps{missing}{y}", "IntList[7]"} {
+		if err := DeletePath(&actual, path); err == nil {
+			t.Errorf("Expected an error for DeletePath(%v)", path)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ghodss/yaml"
+	. "github.com/walmartlabs/object-diff/pkg/obj_diff/helpers"
+	"reflect"
+	"strings"
+)
+
+// How struct fields are named when rendering a ChangeSet.
+type NamingStyle int
+
+const (
+	// Use the Go field names.
+	GoNames NamingStyle = iota
+	// Use the json tag names, inlining embedded structs.
+	JSONNames
+)
+
+// How composite values are shown when rendering a ChangeSet.
+type ValueFormat int
+
+const (
+	// Show composite values as single line JSON.
+	JSONValues ValueFormat = iota
+	// Show composite values as YAML blocks, as they would appear in a
+	// manifest.
+	YAMLValues
+)
+
+// Options for rendering a ChangeSet.
+type RenderOptions struct {
+	// Color removed lines red and added lines green with ANSI escapes.
+	Color bool
+	// How struct fields are named.
+	Naming NamingStyle
+	// How composite values are shown.
+	Format ValueFormat
+}
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+)
+
+// Renders this ChangeSet in a unified diff style, similar to kubectl diff.
+// Changes are grouped into a tree by their path, old values are marked with
+// '-' and new values with '+'. Multi-line strings are compared line by line.
+func (cs ChangeSet) Render(opts RenderOptions) string {
+	root := &renderNode{}
+	for _, change := range cs.Changes {
+		node := root
+		for _, label := range renderLabels(cs.BaseType, change.GetPath(), opts.Naming) {
+			node = node.child(label)
+		}
+		node.changes = append(node.changes, change)
+	}
+
+	r := &renderer{opts: opts}
+	r.renderNode(root, -1)
+	return r.out.String()
+}
+
+// A node in the tree of changes, children are kept in the order they are
+// first seen.
+type renderNode struct {
+	label    string
+	children []*renderNode
+	changes  []Change
+}
+
+func (node *renderNode) child(label string) *renderNode {
+	for _, child := range node.children {
+		if child.label == label {
+			return child
+		}
+	}
+
+	child := &renderNode{label: label}
+	node.children = append(node.children, child)
+	return child
+}
+
+// Returns the label for each step in path, pointers and inlined structs do
+// not have labels.
+func renderLabels(baseType reflect.Type, path []PathElement, naming NamingStyle) []string {
+	labels := []string{}
+	currType := baseType
+	for _, pe := range path {
+		if currType != nil {
+			switch currType.Kind() {
+			case reflect.Struct:
+				if index, err := fieldIndex(currType, pe); err == nil {
+					field := currType.Field(index)
+					currType = field.Type
+					if naming == JSONNames {
+						name, _, inline, err := jsonFieldName(field)
+						if inline {
+							continue
+						} else if err == nil {
+							labels = append(labels, name)
+							continue
+						}
+					}
+				} else {
+					currType = nil
+				}
+			case reflect.Map, reflect.Array, reflect.Slice, reflect.Ptr:
+				currType = currType.Elem()
+			default:
+				currType = nil
+			}
+		}
+
+		switch {
+		case pe.IsPointer():
+		case pe.GetKey().IsValid():
+			labels = append(labels, fmt.Sprint(pe.GetKey().Interface()))
+		case len(pe.GetName()) > 0:
+			labels = append(labels, pe.GetName())
+		default:
+			labels = append(labels, fmt.Sprintf("[%v]", pe.GetIndex()))
+		}
+	}
+
+	return labels
+}
+
+type renderer struct {
+	opts RenderOptions
+	out  strings.Builder
+}
+
+func (r *renderer) renderNode(node *renderNode, depth int) {
+	for _, change := range node.changes {
+		r.renderChange(node.label, change, depth)
+	}
+
+	for _, child := range node.children {
+		if len(child.changes) == 0 {
+			r.line(' ', depth+1, child.label+":")
+		}
+		r.renderNode(child, depth+1)
+	}
+}
+
+func (r *renderer) renderChange(label string, change Change, depth int) {
+	if depth < 0 {
+		depth = 0
+	}
+	prefix := ""
+	if len(label) > 0 {
+		prefix = label + ": "
+	}
+
+	oldValue := change.GetOldValue()
+	newValue := change.GetNewValue()
+	if !change.IsAddition() && !change.IsDeletion() && isMultiLineString(oldValue) && isMultiLineString(newValue) {
+		if len(prefix) == 0 {
+			depth--
+		} else {
+			r.line(' ', depth, prefix+"|")
+		}
+		for _, dl := range diffLines(strings.Split(oldValue.String(), "\n"), strings.Split(newValue.String(), "\n")) {
+			r.line(dl.marker, depth+1, dl.text)
+		}
+		return
+	}
+
+	if !change.IsAddition() {
+		r.value('-', depth, prefix, oldValue)
+	}
+	if !change.IsDeletion() {
+		r.value('+', depth, prefix, newValue)
+	}
+}
+
+func (r *renderer) value(marker byte, depth int, prefix string, value reflect.Value) {
+	formatted := formatRenderValue(value, r.opts.Format)
+	lines := strings.Split(formatted, "\n")
+	if len(lines) == 1 {
+		r.line(marker, depth, prefix+formatted)
+		return
+	}
+
+	if len(prefix) == 0 {
+		depth--
+	} else if value.Kind() == reflect.String {
+		r.line(marker, depth, prefix+"|")
+	} else {
+		r.line(marker, depth, strings.TrimSuffix(prefix, " "))
+	}
+	for _, line := range lines {
+		r.line(marker, depth+1, line)
+	}
+}
+
+func (r *renderer) line(marker byte, depth int, text string) {
+	line := string(marker) + " " + strings.Repeat("  ", depth) + text
+	if r.opts.Color && marker == '-' {
+		line = ansiRed + line + ansiReset
+	} else if r.opts.Color && marker == '+' {
+		line = ansiGreen + line + ansiReset
+	}
+	r.out.WriteString(line + "\n")
+}
+
+func isMultiLineString(value reflect.Value) bool {
+	return value.IsValid() && value.Kind() == reflect.String && strings.Contains(value.String(), "\n")
+}
+
+// Formats a value for display, composite values are shown as JSON or YAML
+// when they can be encoded.
+func formatRenderValue(value reflect.Value, format ValueFormat) string {
+	if !value.IsValid() {
+		return "<nil>"
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		if value.Len() == 0 {
+			return `""`
+		}
+		return value.String()
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr, reflect.Interface:
+		if format == YAMLValues {
+			if encoded, err := yaml.Marshal(value.Interface()); err == nil {
+				return strings.TrimSuffix(string(encoded), "\n")
+			}
+		} else if encoded, err := json.Marshal(value.Interface()); err == nil {
+			return string(encoded)
+		}
+	}
+
+	return fmt.Sprintf("%+v", value.Interface())
+}
+
+// A single line of a line-level diff.
+type diffLine struct {
+	marker byte
+	text   string
+}
+
+// Computes a line-level diff of two texts using their longest common
+// subsequence.
+func diffLines(oldLines []string, newLines []string) []diffLine {
+	// lcs[i][j] is the length of the LCS of oldLines[i:] and newLines[j:].
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = intMax(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := []diffLine{}
+	i, j := 0, 0
+	for i < len(oldLines) && j < len(newLines) {
+		if oldLines[i] == newLines[j] {
+			lines = append(lines, diffLine{' ', oldLines[i]})
+			i++
+			j++
+		} else if lcs[i+1][j] >= lcs[i][j+1] {
+			lines = append(lines, diffLine{'-', oldLines[i]})
+			i++
+		} else {
+			lines = append(lines, diffLine{'+', newLines[j]})
+			j++
+		}
+	}
+	for ; i < len(oldLines); i++ {
+		lines = append(lines, diffLine{'-', oldLines[i]})
+	}
+	for ; j < len(newLines); j++ {
+		lines = append(lines, diffLine{'+', newLines[j]})
+	}
+
+	return lines
+}
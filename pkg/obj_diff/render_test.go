@@ -0,0 +1,119 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"strings"
+	"testing"
+)
+
+type renderObject struct {
+	JSONTestMeta `json:",inline"`
+	Replicas     int               `json:"replicas"`
+	Config       map[string]string `json:"config"`
+	Nested       *NestObj          `json:"nested,omitempty"`
+}
+
+func TestRender(t *testing.T) {
+	o1 := renderObject{
+		JSONTestMeta: JSONTestMeta{Name: "a"},
+		Replicas:     3,
+		Config:       map[string]string{"file": "line1\nline2\nline3", "old": "x"},
+	}
+	o2 := renderObject{
+		JSONTestMeta: JSONTestMeta{Name: "b"},
+		Replicas:     5,
+		Config:       map[string]string{"file": "line1\nline2b\nline3", "new": "y"},
+		Nested:       &NestObj{1, "A"},
+	}
+
+	diff, err := Diff(&o1, &o2)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		opts   RenderOptions
+		expect []string
+	}{
+		{
+			name: "Go Names",
+			opts: RenderOptions{Naming: GoNames},
+			expect: []string{
+				"  JSONTestMeta:",
+				"-   Name: a",
+				"+   Name: b",
+				"- Replicas: 3",
+				"+ Replicas: 5",
+				"  Config:",
+				"    file: |",
+				"      line1",
+				"-     line2",
+				"+     line2b",
+				"      line3",
+				"+   new: y",
+				"-   old: x",
+				`+ Nested: {"Int":1,"Str":"A"}`,
+			},
+		},
+		{
+			name: "JSON Names",
+			opts: RenderOptions{Naming: JSONNames},
+			expect: []string{
+				"- name: a",
+				"+ name: b",
+				"- replicas: 3",
+				"+ replicas: 5",
+				"  config:",
+				"    file: |",
+				"      line1",
+				"-     line2",
+				"+     line2b",
+				"      line3",
+				"+   new: y",
+				"-   old: x",
+				`+ nested: {"Int":1,"Str":"A"}`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := diff.Render(test.opts)
+			expect := strings.Join(test.expect, "\n") + "\n"
+			if expect != actual {
+				t.Logf("Expect:\n%s", expect)
+				t.Logf("Actual:\n%s", actual)
+				t.Fail()
+			}
+		})
+	}
+
+	colored := diff.Render(RenderOptions{Color: true})
+	if !strings.Contains(colored, ansiRed+"-   Name: a"+ansiReset) || !strings.Contains(colored, ansiGreen+"+   Name: b"+ansiReset) {
+		t.Errorf("Expected colored output, got:\n%s", colored)
+	}
+}
+
+func TestRenderYAML(t *testing.T) {
+	o1 := renderObject{Replicas: 3}
+	o2 := renderObject{Replicas: 3, Nested: &NestObj{1, "A"}}
+	diff, err := Diff(o1, o2)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	expect := strings.Join([]string{
+		"+ nested:",
+		"+   Int: 1",
+		"+   Str: A",
+	}, "\n") + "\n"
+	actual := diff.Render(RenderOptions{Naming: JSONNames, Format: YAMLValues})
+	if expect != actual {
+		t.Logf("Expect:\n%s", expect)
+		t.Logf("Actual:\n%s", actual)
+		t.Fail()
+	}
+}
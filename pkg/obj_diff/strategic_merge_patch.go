@@ -0,0 +1,434 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Strategic merge patch directives.
+const (
+	smpPatchDirective       = "$patch"
+	smpDeleteDirective      = "delete"
+	smpReplaceDirective     = "replace"
+	smpRetainKeysDirective  = "$retainKeys"
+	smpSetElementOrder      = "$setElementOrder/"
+	smpDeleteFromPrimitives = "$deleteFromPrimitiveList/"
+)
+
+// Computes a Kubernetes strategic merge patch which transforms original into
+// modified, both must be of the same struct or map type (or pointers to
+// one). Lists are merged according to the patchStrategy and patchMergeKey
+// struct tags used by the Kubernetes API types: lists of objects are merged
+// by their merge key with "$patch: delete" for removed elements, lists of
+// primitives are merged by value, and "$setElementOrder" records the order
+// of any merged list which has changed. The "replace" and "retainKeys"
+// strategies are honored, any other list is replaced as a whole.
+func StrategicMergePatch(original interface{}, modified interface{}) ([]byte, error) {
+	v1 := reflect.ValueOf(original)
+	v2 := reflect.ValueOf(modified)
+	if !v1.IsValid() || !v2.IsValid() || v1.Type() != v2.Type() {
+		return nil, fmt.Errorf("type of original(%T) not equal to modified(%T)", original, modified)
+	}
+
+	for v1.Kind() == reflect.Ptr {
+		if v1.IsNil() || v2.IsNil() {
+			return nil, fmt.Errorf("can not compute a strategic merge patch for nil %v", v1.Type())
+		}
+		v1 = v1.Elem()
+		v2 = v2.Elem()
+	}
+
+	var patch map[string]interface{}
+	var err error
+	switch v1.Kind() {
+	case reflect.Struct:
+		patch, _, err = smpDiffStruct(v1, v2, "")
+	case reflect.Map:
+		patch, _, err = smpDiffMap(v1, v2)
+	default:
+		return nil, fmt.Errorf("can not compute a strategic merge patch for %v", v1.Type())
+	}
+	if err != nil {
+		return nil, err
+	} else if patch == nil {
+		patch = map[string]interface{}{}
+	}
+
+	return json.Marshal(patch)
+}
+
+// Computes a Kubernetes strategic merge patch for the result of applying
+// this ChangeSet to base, see StrategicMergePatch. The base itself is not
+// modified.
+func (cs ChangeSet) StrategicMergePatchFor(base interface{}) ([]byte, error) {
+	modified, err := cs.Apply(base)
+	if err != nil {
+		return nil, err
+	}
+
+	return StrategicMergePatch(base, modified)
+}
+
+// Returns the patch for any value, and true if the values differ.
+func smpDiffValue(v1 reflect.Value, v2 reflect.Value, strategy string) (interface{}, bool, error) {
+	if smpIsOpaque(v1.Type()) {
+		return smpDiffOpaque(v1, v2)
+	}
+
+	switch v1.Kind() {
+	case reflect.Ptr:
+		if v1.IsNil() && v2.IsNil() {
+			return nil, false, nil
+		} else if v2.IsNil() {
+			return nil, true, nil
+		} else if v1.IsNil() {
+			full, err := toJSONValue(v2)
+			return full, true, err
+		}
+		return smpDiffValue(v1.Elem(), v2.Elem(), strategy)
+
+	case reflect.Struct:
+		return smpDiffStruct(v1, v2, strategy)
+
+	case reflect.Map:
+		if strings.Contains(strategy, smpReplaceDirective) || v1.IsNil() || v2.IsNil() {
+			return smpDiffOpaque(v1, v2)
+		}
+		return smpDiffMap(v1, v2)
+	}
+
+	return smpDiffOpaque(v1, v2)
+}
+
+// Values which are not merged are compared by their JSON encoding and
+// replaced as a whole.
+func smpDiffOpaque(v1 reflect.Value, v2 reflect.Value) (interface{}, bool, error) {
+	j1, err := toJSONValue(v1)
+	if err != nil {
+		return nil, false, err
+	}
+	j2, err := toJSONValue(v2)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if reflect.DeepEqual(j1, j2) {
+		return nil, false, nil
+	}
+	return j2, true, nil
+}
+
+// Types which encode themselves, or have fields encoding/json can not see,
+// are treated as a single value.
+func smpIsOpaque(t reflect.Type) bool {
+	marshaler := reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	if t.Implements(marshaler) || reflect.PtrTo(t).Implements(marshaler) {
+		return true
+	}
+
+	if t.Kind() == reflect.Struct {
+		for f := 0; f < t.NumField(); f++ {
+			if len(t.Field(f).PkgPath) > 0 && !t.Field(f).Anonymous {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func smpDiffStruct(v1 reflect.Value, v2 reflect.Value, strategy string) (map[string]interface{}, bool, error) {
+	patch := map[string]interface{}{}
+	if err := smpDiffFields(v1, v2, patch); err != nil {
+		return nil, false, err
+	}
+
+	if len(patch) == 0 {
+		return nil, false, nil
+	}
+
+	if strings.Contains(strategy, smpReplaceDirective) {
+		full, err := toJSONValue(v2)
+		if err != nil {
+			return nil, false, err
+		}
+		fullMap, ok := full.(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("%v can not be replaced, it is not encoded as an object", v2.Type())
+		}
+		fullMap[smpPatchDirective] = smpReplaceDirective
+		return fullMap, true, nil
+	}
+
+	if strings.Contains(strategy, "retainKeys") {
+		retained, err := smpPresentKeys(v2)
+		if err != nil {
+			return nil, false, err
+		}
+		patch[smpRetainKeysDirective] = retained
+	}
+	return patch, true, nil
+}
+
+// Adds the patch for each field of v1 and v2 to patch, embedded structs
+// are inlined as they are by encoding/json.
+func smpDiffFields(v1 reflect.Value, v2 reflect.Value, patch map[string]interface{}) error {
+	structType := v1.Type()
+	for f := 0; f < structType.NumField(); f++ {
+		field := structType.Field(f)
+		name, _, inline, err := jsonFieldName(field)
+		if err != nil {
+			// Not part of the JSON encoding.
+			continue
+		}
+
+		f1 := v1.Field(f)
+		f2 := v2.Field(f)
+		if inline {
+			if field.Type.Kind() == reflect.Ptr {
+				if f1.IsNil() || f2.IsNil() {
+					return fmt.Errorf("can not patch nil embedded %v", field.Type)
+				}
+				f1 = f1.Elem()
+				f2 = f2.Elem()
+			}
+			if err := smpDiffFields(f1, f2, patch); err != nil {
+				return err
+			}
+			continue
+		}
+
+		strategy := field.Tag.Get("patchStrategy")
+		if field.Type.Kind() == reflect.Slice && strings.Contains(strategy, "merge") {
+			if err := smpDiffMergeList(f1, f2, name, field.Tag.Get("patchMergeKey"), strategy, patch); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fieldPatch, changed, err := smpDiffValue(f1, f2, strategy)
+		if err != nil {
+			return err
+		}
+		if changed {
+			patch[name] = fieldPatch
+		}
+	}
+
+	return nil
+}
+
+func smpDiffMap(v1 reflect.Value, v2 reflect.Value) (map[string]interface{}, bool, error) {
+	patch := map[string]interface{}{}
+	for _, key := range sortedMapKeys(v1, v2) {
+		name, err := jsonMapKey(key)
+		if err != nil {
+			return nil, false, err
+		}
+
+		val1 := v1.MapIndex(key)
+		val2 := v2.MapIndex(key)
+		if !val2.IsValid() {
+			patch[name] = nil
+		} else if !val1.IsValid() {
+			if patch[name], err = toJSONValue(val2); err != nil {
+				return nil, false, err
+			}
+		} else {
+			valuePatch, changed, err := smpDiffValue(val1, val2, "")
+			if err != nil {
+				return nil, false, err
+			}
+			if changed {
+				patch[name] = valuePatch
+			}
+		}
+	}
+
+	if len(patch) == 0 {
+		return nil, false, nil
+	}
+	return patch, true, nil
+}
+
+// Adds the patch for a list with the merge strategy to patch.
+func smpDiffMergeList(l1 reflect.Value, l2 reflect.Value, name string, mergeKey string, strategy string, patch map[string]interface{}) error {
+	elemType := l1.Type().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	if elemType.Kind() != reflect.Struct {
+		return smpDiffPrimitiveList(l1, l2, name, patch)
+	}
+	if len(mergeKey) == 0 {
+		return fmt.Errorf("list %v of %v has no patchMergeKey", name, elemType)
+	}
+
+	originals := map[string]reflect.Value{}
+	originalOrder := []string{}
+	for i := 0; i < l1.Len(); i++ {
+		_, id, err := smpMergeKey(l1.Index(i), mergeKey)
+		if err != nil {
+			return err
+		}
+		originals[id] = l1.Index(i)
+		originalOrder = append(originalOrder, id)
+	}
+
+	listPatch := []interface{}{}
+	order := []interface{}{}
+	modifiedOrder := []string{}
+	present := map[string]bool{}
+	for i := 0; i < l2.Len(); i++ {
+		elem := l2.Index(i)
+		keyValue, id, err := smpMergeKey(elem, mergeKey)
+		if err != nil {
+			return err
+		}
+		present[id] = true
+		order = append(order, map[string]interface{}{mergeKey: keyValue})
+
+		original, exists := originals[id]
+		if !exists {
+			full, err := toJSONValue(elem)
+			if err != nil {
+				return err
+			}
+			listPatch = append(listPatch, full)
+			continue
+		}
+		modifiedOrder = append(modifiedOrder, id)
+
+		elemPatch, changed, err := smpDiffValue(original, elem, strings.Replace(strategy, "merge", "", 1))
+		if err != nil {
+			return err
+		}
+		if changed {
+			elemMap, ok := elemPatch.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("list %v element %v can not be merged", name, id)
+			}
+			elemMap[mergeKey] = keyValue
+			listPatch = append(listPatch, elemMap)
+		}
+	}
+
+	keptOrder := []string{}
+	for _, id := range originalOrder {
+		if !present[id] {
+			keyValue, _, _ := smpMergeKey(originals[id], mergeKey)
+			listPatch = append(listPatch, map[string]interface{}{mergeKey: keyValue, smpPatchDirective: smpDeleteDirective})
+		} else {
+			keptOrder = append(keptOrder, id)
+		}
+	}
+
+	if len(listPatch) == 0 && reflect.DeepEqual(keptOrder, modifiedOrder) {
+		return nil
+	}
+	if len(listPatch) > 0 {
+		patch[name] = listPatch
+	}
+	patch[smpSetElementOrder+name] = order
+	return nil
+}
+
+// Adds the patch for a list of primitives with the merge strategy to patch.
+func smpDiffPrimitiveList(l1 reflect.Value, l2 reflect.Value, name string, patch map[string]interface{}) error {
+	j1, err := smpListValues(l1)
+	if err != nil {
+		return err
+	}
+	j2, err := smpListValues(l2)
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(j1, j2) {
+		return nil
+	}
+
+	added := []interface{}{}
+	for _, v2 := range j2 {
+		if !smpContains(j1, v2) {
+			added = append(added, v2)
+		}
+	}
+	deleted := []interface{}{}
+	for _, v1 := range j1 {
+		if !smpContains(j2, v1) {
+			deleted = append(deleted, v1)
+		}
+	}
+
+	if len(added) > 0 {
+		patch[name] = added
+	}
+	if len(deleted) > 0 {
+		patch[smpDeleteFromPrimitives+name] = deleted
+	}
+	patch[smpSetElementOrder+name] = j2
+	return nil
+}
+
+func smpListValues(list reflect.Value) ([]interface{}, error) {
+	values := []interface{}{}
+	for i := 0; i < list.Len(); i++ {
+		value, err := toJSONValue(list.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func smpContains(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the JSON value of the mergeKey member of elem and a string which
+// identifies it.
+func smpMergeKey(elem reflect.Value, mergeKey string) (interface{}, string, error) {
+	full, err := toJSONValue(elem)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fullMap, ok := full.(map[string]interface{})
+	if !ok {
+		return nil, "", fmt.Errorf("list element %v is not an object", elem.Type())
+	}
+
+	keyValue := fullMap[mergeKey]
+	id, err := json.Marshal(keyValue)
+	return keyValue, string(id), err
+}
+
+// Returns the sorted member names present in the JSON encoding of value.
+func smpPresentKeys(value reflect.Value) ([]string, error) {
+	full, err := toJSONValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	fullMap, _ := full.(map[string]interface{})
+	for key := range fullMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) Walmart Inc.
+//
+// This source code is licensed under the Apache 2.0 license found in the
+// LICENSE file in the root directory of this source tree.
+package obj_diff
+
+import (
+	"encoding/json"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+)
+
+func buildPods() (v1.Pod, v1.Pod) {
+	original := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "pod",
+			Labels:     map[string]string{"app": "a", "team": "x"},
+			Finalizers: []string{"a", "b"},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Name: "app", Image: "app:1", Env: []v1.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}}},
+				{Name: "sidecar", Image: "sidecar:1"},
+			},
+			Volumes: []v1.Volume{
+				{Name: "data", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+			},
+			Tolerations: []v1.Toleration{{Key: "a"}},
+		},
+	}
+
+	modified := *original.DeepCopy()
+	modified.Labels["app"] = "b"
+	delete(modified.Labels, "team")
+	modified.Finalizers = []string{"b", "c"}
+	modified.Spec.Containers = []v1.Container{
+		{Name: "logger", Image: "logger:1"},
+		{Name: "app", Image: "app:2", Env: []v1.EnvVar{{Name: "A", Value: "1"}},
+			Resources: v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}}},
+	}
+	modified.Spec.Volumes[0].VolumeSource = v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/data"}}
+	modified.Spec.Tolerations = []v1.Toleration{{Key: "b"}}
+	return original, modified
+}
+
+func TestStrategicMergePatch(t *testing.T) {
+	original, modified := buildPods()
+	actual, err := StrategicMergePatch(&original, &modified)
+	if err != nil {
+		t.Fatalf("Error in StrategicMergePatch: %v", err)
+	}
+
+	expect := `{
+		"metadata": {
+			"$deleteFromPrimitiveList/finalizers": ["a"],
+			"$setElementOrder/finalizers": ["b", "c"],
+			"finalizers": ["c"],
+			"labels": {"app": "b", "team": null}
+		},
+		"spec": {
+			"$setElementOrder/containers": [{"name": "logger"}, {"name": "app"}],
+			"$setElementOrder/volumes": [{"name": "data"}],
+			"containers": [
+				{"image": "logger:1", "name": "logger", "resources": {}},
+				{"$setElementOrder/env": [{"name": "A"}], "env": [{"$patch": "delete", "name": "B"}],
+					"image": "app:2", "name": "app", "resources": {"limits": {"memory": "1Gi"}}},
+				{"$patch": "delete", "name": "sidecar"}
+			],
+			"tolerations": [{"key": "b"}],
+			"volumes": [{"$retainKeys": ["hostPath", "name"], "emptyDir": null, "hostPath": {"path": "/data"}, "name": "data"}]
+		}
+	}`
+	assertJSONEqual(t, expect, actual)
+
+	unchanged, err := StrategicMergePatch(original, original)
+	if err != nil {
+		t.Fatalf("Error in StrategicMergePatch: %v", err)
+	}
+	assertJSONEqual(t, `{}`, unchanged)
+}
+
+func TestChangeSetStrategicMergePatch(t *testing.T) {
+	original, modified := buildPods()
+	diff, err := Diff(original.Spec, modified.Spec)
+	if err != nil {
+		t.Fatalf("Error in Diff: %v", err)
+	}
+
+	fromChanges, err := diff.StrategicMergePatchFor(original.Spec)
+	if err != nil {
+		t.Fatalf("Error in StrategicMergePatchFor: %v", err)
+	}
+	fromObjects, err := StrategicMergePatch(original.Spec, modified.Spec)
+	if err != nil {
+		t.Fatalf("Error in StrategicMergePatch: %v", err)
+	}
+	assertJSONEqual(t, string(fromObjects), fromChanges)
+
+	if _, err := StrategicMergePatch(original, modified.Spec); err == nil {
+		t.Errorf("Expected an error for mismatched types")
+	}
+}
+
+func assertJSONEqual(t *testing.T, expect string, actual []byte) {
+	var expectValue, actualValue interface{}
+	if err := json.Unmarshal([]byte(expect), &expectValue); err != nil {
+		t.Fatalf("Invalid expected JSON: %v", err)
+	}
+	if err := json.Unmarshal(actual, &actualValue); err != nil {
+		t.Fatalf("Invalid actual JSON: %v", err)
+	}
+
+	if !reflect.DeepEqual(expectValue, actualValue) {
+		t.Logf("Expect: %s", expect)
+		t.Logf("Actual: %s", actual)
+		t.Fail()
+	}
+}